@@ -158,7 +158,15 @@ async function loadConvertFileList(dir) {
 async function startConvert() {
     if (!convertDir) return;
 
-    const percent = parseInt(document.getElementById('convert-percent-slider').value);
+    const opts = {
+        percent: parseInt(document.getElementById('convert-percent-slider').value),
+        format: document.getElementById('convert-format').value,
+        colorMode: document.getElementById('convert-color-mode').value,
+        quality: parseInt(document.getElementById('convert-quality').value) || 92,
+        concurrency: parseInt(document.getElementById('convert-workers').value) || 0,
+        memoryBudgetMb: parseInt(document.getElementById('convert-memory').value) || 0,
+        autoOrient: document.getElementById('convert-auto-orient').checked,
+    };
 
     document.getElementById('convert-log-area').innerHTML = '';
     document.getElementById('convert-progress-bar').style.width = '0%';
@@ -177,7 +185,7 @@ async function startConvert() {
 
     try {
         const app = await getApp();
-        const result = await app.StartConvert(convertDir, percent);
+        const result = await app.StartConvert(convertDir, opts);
         if (result) {
             const logArea = document.getElementById('convert-log-area');
             const div = document.createElement('div');
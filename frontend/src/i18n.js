@@ -52,13 +52,73 @@ const T = {
     'label.apiKey': 'API 金鑰：',
     'label.ocrLang': 'OCR 語言：',
     'label.concurrency': '併發數量：',
+    'label.auto': '自動',
+    'tooltip.concurrency': '同時處理的檔案數。自動：Tesseract 使用 CPU 核心數，其他服務從 5 開始（不超過服務上限）；服務限流時自動減少，請求順利時逐步增加',
+    'label.quotaFallback': '額度用完時：',
+    'opt.quotaStop': '停止',
+    'opt.quotaTesseract': '改用 Tesseract',
+    'opt.quotaOcrSpace': '改用 OCR.space',
+    'opt.quotaGoogle': '改用 Google Vision',
+    'tooltip.quotaFallback': '每日或每月呼叫額度（config.json 的 quotaBudgets，預設不設限）用完時的處理方式；停止時剩餘檔案會保留在工作階段中',
+    'label.consensus': '共識投票：',
+    'tooltip.consensus': '每頁也交給勾選的引擎辨識，依信心度逐字投票合併結果；有分歧的字詞標記在輸出資料夾的 review/ 中',
+    'label.visionAuth': '驗證方式：',
+    'opt.visionAuthFile': '服務帳戶金鑰檔',
+    'opt.visionAuthAdc': '應用程式預設憑證 (ADC)',
+    'opt.visionAuthApiKey': 'API Key',
+    'placeholder.visionApiKey': 'API Key（驗證方式為 API Key 時）',
+    'msg.enterVisionApiKey': '請輸入 Google Vision 的 API Key',
+    'label.ocrSpaceUrl': 'API 端點：',
+    'tooltip.ocrSpaceUrl': '留空使用公開 API；PRO 方案請填入 OCR.space 提供的端點',
+    'label.escalate': '品質不足時：',
+    'tooltip.escalate': '頁面的信心度或字詞命中率低於門檻時，改用另一個引擎重新辨識',
+    'opt.escalateOff': '保留結果',
+    'opt.escalateGoogle': '改用 Google Vision',
+    'opt.escalateOcrSpace': '改用 OCR.space',
+    'opt.escalateTesseract': '改用 Tesseract',
+    'label.escalateBelow': '分數低於',
+    'label.fallbacks': '備援引擎：',
+    'tooltip.fallbacks': '某頁在目前引擎重試後仍失敗時，依序改用勾選的引擎',
     'label.mergePdf': '合併 PDF：',
     'placeholder.mergeFilename': '合併檔名',
     'btn.startOcr': '開始 OCR',
+    'btn.renderOcr': '重新產生 PDF',
+    'tooltip.renderOcr': '由已儲存的 OCR 結果重新產生 PDF，不呼叫 OCR 服務',
+    'btn.retryFailed': '重試失敗',
+    'tooltip.retryFailed': '只重新處理先前失敗的檔案，可先改用其他 OCR 引擎',
+    'btn.addQueue': '加入佇列',
+    'tooltip.addQueue': '以目前設定將此資料夾加入工作佇列',
+    'btn.estimate': '估算費用',
+    'tooltip.estimate': '估算剩餘頁數的費用與本月剩餘免費額度，不呼叫 OCR 服務',
+    'btn.startQueue': '執行佇列',
+    'btn.clearFinished': '清除已結束',
+    'btn.cancel': '取消',
+    'tooltip.moveUp': '上移',
+    'tooltip.moveDown': '下移',
     'btn.stop': '停止',
+    'btn.pause': '暫停',
+    'btn.continue': '繼續',
+    'tooltip.pause': '處理中的檔案完成後暫停，不再開始新檔案',
     // Convert tab
     'label.scalePercent': '縮小比例：',
     'btn.startConvert': '開始轉檔',
+    'label.convertFormat': '輸出格式：',
+    'opt.keepFormat': '保留原格式',
+    'opt.tiffLossless': 'TIFF（無損）',
+    'opt.tiffG4': 'TIFF（Group 4 黑白）',
+    'label.colorMode': '色彩模式：',
+    'opt.keepColor': '保留原色彩',
+    'opt.grayscale': '灰階',
+    'opt.bilevel': '黑白（1-bit）',
+    'label.jpegQuality': '品質：',
+    'label.convertWorkers': '工作執行緒：',
+    'hint.autoZero': '（0 = 自動）',
+    'label.memoryBudget': '記憶體上限 (MB)：',
+    'label.autoOrient': '自動轉正：',
+    'hint.autoOrient': '（偵測頁面方向與傾斜，OCR 前自動旋轉）',
+    'label.imageOnly': '僅圖片轉 PDF：',
+    'hint.imageOnly': '（不做 OCR，直接由圖片產生 PDF；之後再 OCR 會補上文字層）',
+    'hint.convertAutoOrient': '（偵測方向與傾斜並直接修正檔案）',
     // Convert file list
     'header.filename': '檔名',
     'header.dimensions': '尺寸',
@@ -90,7 +150,16 @@ const T = {
     'msg.unchanged': '（不變）',
     'msg.noImagesInDir': '資料夾中沒有圖片',
     'msg.convertInProgress': '轉檔進行中',
-    'msg.sessionResume': '上次處理到第 {processed}/{total} 張，是否繼續？',
+    'msg.sessionResume': '{dir}：上次處理到第 {processed}/{total} 張，是否繼續？',
+    'msg.queueEmpty': '佇列是空的',
+    'msg.queueJob': '{dir}（{count} 個檔案）',
+    'msg.cannotQueue': '無法加入佇列：',
+    'msg.cannotEstimate': '無法估算費用：',
+    'queue.pending': '等待中',
+    'queue.running': '執行中',
+    'queue.done': '完成',
+    'queue.failed': '失敗',
+    'queue.cancelled': '已取消',
     'msg.loadedImages': '已載入 {count} 張圖片',
     'msg.restoredSettings': '，已還原 {count} 筆設定',
     'msg.reloadFolder': '重新讀取：',
@@ -142,6 +211,7 @@ const T = {
     'label.settings': '設定',
     'tab.ocrPreview': '圖片預覽',
     'tab.ocrLog': '系統訊息',
+    'tab.ocrQueue': '工作佇列',
     'label.tesseractPath': 'Tesseract 路徑：',
     'btn.detect': '自動偵測',
     'msg.tesseractNotFound': '未找到 Tesseract，請手動選擇 tesseract.exe',
@@ -185,12 +255,72 @@ const T = {
     'label.apiKey': 'API Key:',
     'label.ocrLang': 'OCR Language:',
     'label.concurrency': 'Concurrency:',
+    'label.auto': 'Auto',
+    'tooltip.concurrency': 'Files processed at once. Auto: the CPU count for Tesseract, 5 for the other providers, within their limit; lowered when the provider throttles and raised again while requests succeed',
+    'label.quotaFallback': 'When quota runs out:',
+    'opt.quotaStop': 'Stop',
+    'opt.quotaTesseract': 'Switch to Tesseract',
+    'opt.quotaOcrSpace': 'Switch to OCR.space',
+    'opt.quotaGoogle': 'Switch to Google Vision',
+    'tooltip.quotaFallback': 'What to do when a daily or monthly call budget (quotaBudgets in config.json, none by default) is used up; on stop the remaining files stay in the session',
+    'label.consensus': 'Consensus:',
+    'tooltip.consensus': 'Every page is also OCR\'d by the checked engines and their words are voted by confidence; disputed words are marked in review/ in the output folder',
+    'label.visionAuth': 'Sign in with:',
+    'opt.visionAuthFile': 'Service account key file',
+    'opt.visionAuthAdc': 'Application Default Credentials',
+    'opt.visionAuthApiKey': 'API key',
+    'placeholder.visionApiKey': 'API key (for API key sign-in)',
+    'msg.enterVisionApiKey': 'Please enter a Google Vision API key',
+    'label.ocrSpaceUrl': 'Endpoint:',
+    'tooltip.ocrSpaceUrl': 'Leave empty for the public API; on the PRO plan enter the endpoint OCR.space gave you',
+    'label.escalate': 'Weak pages:',
+    'tooltip.escalate': 'Pages whose word confidence or word hit rate is below the threshold are OCR\'d again with another engine',
+    'opt.escalateOff': 'Keep the result',
+    'opt.escalateGoogle': 'Redo with Google Vision',
+    'opt.escalateOcrSpace': 'Redo with OCR.space',
+    'opt.escalateTesseract': 'Redo with Tesseract',
+    'label.escalateBelow': 'score below',
+    'label.fallbacks': 'Fallback engines:',
+    'tooltip.fallbacks': 'A page that still fails on the current engine after retries is tried on the checked engines in turn',
     'label.mergePdf': 'Merge PDF:',
     'placeholder.mergeFilename': 'Merge filename',
     'btn.startOcr': 'Start OCR',
+    'btn.renderOcr': 'Re-render PDFs',
+    'tooltip.renderOcr': 'Rebuild the PDFs from stored OCR results without calling the OCR service',
+    'btn.retryFailed': 'Retry Failed',
+    'tooltip.retryFailed': 'Run only the files that failed before; you can switch to another OCR engine first',
+    'btn.addQueue': 'Add to Queue',
+    'tooltip.addQueue': 'Queue this folder with the current settings',
+    'btn.estimate': 'Estimate Cost',
+    'tooltip.estimate': 'Estimate the cost of the pages still to do and the free calls left this month, without calling the OCR service',
+    'btn.startQueue': 'Run Queue',
+    'btn.clearFinished': 'Clear Finished',
+    'btn.cancel': 'Cancel',
+    'tooltip.moveUp': 'Move up',
+    'tooltip.moveDown': 'Move down',
     'btn.stop': 'Stop',
+    'btn.pause': 'Pause',
+    'btn.continue': 'Continue',
+    'tooltip.pause': 'Stop starting new files; files in progress finish and are saved',
     'label.scalePercent': 'Scale:',
     'btn.startConvert': 'Start Convert',
+    'label.convertFormat': 'Output format:',
+    'opt.keepFormat': 'Keep original',
+    'opt.tiffLossless': 'TIFF (lossless)',
+    'opt.tiffG4': 'TIFF (Group 4 B/W)',
+    'label.colorMode': 'Color mode:',
+    'opt.keepColor': 'Keep original',
+    'opt.grayscale': 'Grayscale',
+    'opt.bilevel': 'Black & white (1-bit)',
+    'label.jpegQuality': 'Quality:',
+    'label.convertWorkers': 'Workers:',
+    'hint.autoZero': '(0 = auto)',
+    'label.memoryBudget': 'Memory budget (MB):',
+    'label.autoOrient': 'Auto orient:',
+    'hint.autoOrient': '(detect page rotation and skew, fix before OCR)',
+    'label.imageOnly': 'Images to PDF only:',
+    'hint.imageOnly': '(no OCR, build PDFs from the images; a later OCR run adds the text layer)',
+    'hint.convertAutoOrient': '(detect rotation and skew and fix the files)',
     'header.filename': 'Filename',
     'header.dimensions': 'Dimensions',
     'header.fileSize': 'File Size',
@@ -219,7 +349,16 @@ const T = {
     'msg.unchanged': '(unchanged)',
     'msg.noImagesInDir': 'No images in folder',
     'msg.convertInProgress': 'Conversion in progress',
-    'msg.sessionResume': 'Last session: {processed}/{total} processed. Resume?',
+    'msg.sessionResume': '{dir}: {processed}/{total} processed. Resume?',
+    'msg.queueEmpty': 'The queue is empty',
+    'msg.queueJob': '{dir} ({count} files)',
+    'msg.cannotQueue': 'Cannot add to queue: ',
+    'msg.cannotEstimate': 'Cannot estimate cost: ',
+    'queue.pending': 'Pending',
+    'queue.running': 'Running',
+    'queue.done': 'Done',
+    'queue.failed': 'Failed',
+    'queue.cancelled': 'Cancelled',
     'msg.loadedImages': 'Loaded {count} images',
     'msg.restoredSettings': ', restored {count} settings',
     'msg.reloadFolder': 'Reloading: ',
@@ -271,6 +410,7 @@ const T = {
     'label.settings': 'Settings',
     'tab.ocrPreview': 'Image Preview',
     'tab.ocrLog': 'System Log',
+    'tab.ocrQueue': 'Job Queue',
     'label.tesseractPath': 'Tesseract Path:',
     'btn.detect': 'Auto Detect',
     'msg.tesseractNotFound': 'Tesseract not found. Please select tesseract.exe manually.',
@@ -314,12 +454,72 @@ const T = {
     'label.apiKey': 'API 密钥：',
     'label.ocrLang': 'OCR 语言：',
     'label.concurrency': '并发数量：',
+    'label.auto': '自动',
+    'tooltip.concurrency': '同时处理的文件数。自动：Tesseract 使用 CPU 核心数，其他服务从 5 开始（不超过服务上限）；服务限流时自动减少，请求顺利时逐步增加',
+    'label.quotaFallback': '额度用完时：',
+    'opt.quotaStop': '停止',
+    'opt.quotaTesseract': '改用 Tesseract',
+    'opt.quotaOcrSpace': '改用 OCR.space',
+    'opt.quotaGoogle': '改用 Google Vision',
+    'tooltip.quotaFallback': '每日或每月调用额度（config.json 的 quotaBudgets，默认不设限）用完时的处理方式；停止时剩余文件会保留在会话中',
+    'label.consensus': '共识投票：',
+    'tooltip.consensus': '每页也交给勾选的引擎识别，按置信度逐词投票合并结果；有分歧的词语标记在输出文件夹的 review/ 中',
+    'label.visionAuth': '验证方式：',
+    'opt.visionAuthFile': '服务账号密钥文件',
+    'opt.visionAuthAdc': '应用默认凭据 (ADC)',
+    'opt.visionAuthApiKey': 'API Key',
+    'placeholder.visionApiKey': 'API Key（验证方式为 API Key 时）',
+    'msg.enterVisionApiKey': '请输入 Google Vision 的 API Key',
+    'label.ocrSpaceUrl': 'API 端点：',
+    'tooltip.ocrSpaceUrl': '留空使用公开 API；PRO 方案请填入 OCR.space 提供的端点',
+    'label.escalate': '质量不足时：',
+    'tooltip.escalate': '页面的置信度或词语命中率低于阈值时，改用另一个引擎重新识别',
+    'opt.escalateOff': '保留结果',
+    'opt.escalateGoogle': '改用 Google Vision',
+    'opt.escalateOcrSpace': '改用 OCR.space',
+    'opt.escalateTesseract': '改用 Tesseract',
+    'label.escalateBelow': '分数低于',
+    'label.fallbacks': '备用引擎：',
+    'tooltip.fallbacks': '某页在当前引擎重试后仍失败时，依次改用勾选的引擎',
     'label.mergePdf': '合并 PDF：',
     'placeholder.mergeFilename': '合并文件名',
     'btn.startOcr': '开始 OCR',
+    'btn.renderOcr': '重新生成 PDF',
+    'tooltip.renderOcr': '由已保存的 OCR 结果重新生成 PDF，不调用 OCR 服务',
+    'btn.retryFailed': '重试失败',
+    'tooltip.retryFailed': '只重新处理先前失败的文件，可先改用其他 OCR 引擎',
+    'btn.addQueue': '加入队列',
+    'tooltip.addQueue': '以当前设置将此文件夹加入任务队列',
+    'btn.estimate': '估算费用',
+    'tooltip.estimate': '估算剩余页数的费用与本月剩余免费额度，不调用 OCR 服务',
+    'btn.startQueue': '运行队列',
+    'btn.clearFinished': '清除已结束',
+    'btn.cancel': '取消',
+    'tooltip.moveUp': '上移',
+    'tooltip.moveDown': '下移',
     'btn.stop': '停止',
+    'btn.pause': '暂停',
+    'btn.continue': '继续',
+    'tooltip.pause': '处理中的文件完成后暂停，不再开始新文件',
     'label.scalePercent': '缩小比例：',
     'btn.startConvert': '开始转换',
+    'label.convertFormat': '输出格式：',
+    'opt.keepFormat': '保留原格式',
+    'opt.tiffLossless': 'TIFF（无损）',
+    'opt.tiffG4': 'TIFF（Group 4 黑白）',
+    'label.colorMode': '色彩模式：',
+    'opt.keepColor': '保留原色彩',
+    'opt.grayscale': '灰度',
+    'opt.bilevel': '黑白（1-bit）',
+    'label.jpegQuality': '质量：',
+    'label.convertWorkers': '工作线程：',
+    'hint.autoZero': '（0 = 自动）',
+    'label.memoryBudget': '内存上限 (MB)：',
+    'label.autoOrient': '自动转正：',
+    'hint.autoOrient': '（检测页面方向与倾斜，OCR 前自动旋转）',
+    'label.imageOnly': '仅图片转 PDF：',
+    'hint.imageOnly': '（不做 OCR，直接由图片生成 PDF；之后再 OCR 会补上文字层）',
+    'hint.convertAutoOrient': '（检测方向与倾斜并直接修正文件）',
     'header.filename': '文件名',
     'header.dimensions': '尺寸',
     'header.fileSize': '文件大小',
@@ -348,7 +548,16 @@ const T = {
     'msg.unchanged': '（不变）',
     'msg.noImagesInDir': '文件夹中没有图片',
     'msg.convertInProgress': '转换进行中',
-    'msg.sessionResume': '上次处理到第 {processed}/{total} 张，是否继续？',
+    'msg.sessionResume': '{dir}：上次处理到第 {processed}/{total} 张，是否继续？',
+    'msg.queueEmpty': '队列为空',
+    'msg.queueJob': '{dir}（{count} 个文件）',
+    'msg.cannotQueue': '无法加入队列：',
+    'msg.cannotEstimate': '无法估算费用：',
+    'queue.pending': '等待中',
+    'queue.running': '运行中',
+    'queue.done': '完成',
+    'queue.failed': '失败',
+    'queue.cancelled': '已取消',
     'msg.loadedImages': '已加载 {count} 张图片',
     'msg.restoredSettings': '，已还原 {count} 笔设定',
     'msg.reloadFolder': '重新读取：',
@@ -400,6 +609,7 @@ const T = {
     'label.settings': '设定',
     'tab.ocrPreview': '图片预览',
     'tab.ocrLog': '系统消息',
+    'tab.ocrQueue': '任务队列',
     'label.tesseractPath': 'Tesseract 路径：',
     'btn.detect': '自动检测',
     'msg.tesseractNotFound': '未找到 Tesseract，请手动选择 tesseract.exe',
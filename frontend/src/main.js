@@ -41,7 +41,7 @@ async function init() {
         config = await App.GetConfig();
     } catch (e) {
         console.error('Failed to load config:', e);
-        config = { theme: 'dark', languages: ['en'], concurrency: 5, mergePdf: true, mergeFilename: 'Merge.pdf', uiLang: 'zh-TW' };
+        config = { theme: 'dark', languages: ['en'], concurrency: 0, mergePdf: true, mergeFilename: 'Merge.pdf', uiLang: 'zh-TW' };
     }
 
     // Initialize UI language FIRST so everything renders in correct language
@@ -82,14 +82,14 @@ async function init() {
         log(t('msg.statsTabInitFailed') + e, true);
     }
 
-    // Check for pending session
+    // Check for interrupted jobs
     try {
-        const session = await App.GetPendingSession();
-        if (session && session.imageDir) {
-            showSessionBanner(session, App.ClearSession);
+        const sessions = await App.GetPendingSessions();
+        if (sessions && sessions.length > 0) {
+            showSessionBanner(sessions, App.ClearSession);
         }
     } catch (e) {
-        console.error('Failed to check session:', e);
+        console.error('Failed to check sessions:', e);
     }
 
     log(t('status.ready'), false);
@@ -145,28 +145,54 @@ function switchTab(tabName) {
     });
 }
 
-function showSessionBanner(session, clearSessionFn) {
+// showSessionBanner lists every interrupted OCR job with its own
+// Resume / Dismiss buttons
+function showSessionBanner(sessions, clearSessionFn) {
     const banner = document.getElementById('session-banner');
-    const text = document.getElementById('session-banner-text');
+    const list = document.getElementById('session-list');
+    list.innerHTML = '';
+
+    const removeRow = (row) => {
+        row.remove();
+        if (!list.children.length) banner.classList.add('hidden');
+    };
+
+    sessions.forEach(session => {
+        const row = document.createElement('div');
+        row.className = 'session-row';
+
+        const text = document.createElement('span');
+        text.className = 'session-text';
+        const processed = session.processedFiles ? session.processedFiles.length : 0;
+        text.textContent = t('msg.sessionResume', { dir: session.imageDir, processed, total: session.totalFiles });
+        text.title = session.imageDir + ' \u2192 ' + session.outputDir;
+
+        const resumeBtn = document.createElement('button');
+        resumeBtn.className = 'btn btn-sm btn-primary';
+        resumeBtn.textContent = t('btn.resume');
+        resumeBtn.addEventListener('click', () => {
+            removeRow(row);
+            switchTab('ocr');
+            resumeOCR(session);
+        }, { once: true });
+
+        const dismissBtn = document.createElement('button');
+        dismissBtn.className = 'btn btn-sm btn-secondary';
+        dismissBtn.textContent = t('btn.dismiss');
+        dismissBtn.addEventListener('click', async () => {
+            removeRow(row);
+            try {
+                await clearSessionFn(session.jobId);
+            } catch (e) {
+                console.error('Failed to clear session:', e);
+            }
+        }, { once: true });
+
+        row.append(text, resumeBtn, dismissBtn);
+        list.appendChild(row);
+    });
 
-    const processed = session.processedFiles ? session.processedFiles.length : 0;
-    text.textContent = t('msg.sessionResume', { processed, total: session.totalFiles });
     banner.classList.remove('hidden');
-
-    document.getElementById('session-resume-btn').addEventListener('click', () => {
-        banner.classList.add('hidden');
-        switchTab('ocr');
-        resumeOCR(session);
-    }, { once: true });
-
-    document.getElementById('session-dismiss-btn').addEventListener('click', async () => {
-        banner.classList.add('hidden');
-        try {
-            await clearSessionFn();
-        } catch (e) {
-            console.error('Failed to clear session:', e);
-        }
-    }, { once: true });
 }
 
 // Initialize when DOM is ready
@@ -38,7 +38,14 @@ export async function initOCRTab(config) {
     document.getElementById('ocr-image-dir-btn').addEventListener('click', selectImageDir);
     document.getElementById('ocr-output-dir-btn').addEventListener('click', selectOutputDir);
     document.getElementById('ocr-cred-btn').addEventListener('click', selectCredFile);
-    document.getElementById('start-ocr-btn').addEventListener('click', startOCR);
+    document.getElementById('start-ocr-btn').addEventListener('click', () => startOCR());
+    document.getElementById('retry-failed-btn').addEventListener('click', () => startOCR(false, true));
+    document.getElementById('render-ocr-btn').addEventListener('click', () => startOCR(true));
+    document.getElementById('add-queue-btn').addEventListener('click', enqueueOCR);
+    document.getElementById('estimate-ocr-btn').addEventListener('click', estimateOCR);
+    document.getElementById('start-queue-btn').addEventListener('click', startQueue);
+    document.getElementById('clear-queue-btn').addEventListener('click', clearFinishedJobs);
+    document.getElementById('pause-ocr-btn').addEventListener('click', togglePauseOCR);
     document.getElementById('stop-ocr-btn').addEventListener('click', stopOCR);
 
     // Scan mode toggle: sync rename tab when OCR tab changes
@@ -93,7 +100,7 @@ export async function initOCRTab(config) {
     // Concurrency slider
     const slider = document.getElementById('concurrency-slider');
     slider.addEventListener('input', (e) => {
-        document.getElementById('concurrency-value').textContent = e.target.value;
+        showConcurrency(e.target.value);
     });
 
     // Populate languages
@@ -103,10 +110,8 @@ export async function initOCRTab(config) {
     if (config.credFile) {
         document.getElementById('ocr-cred-label').textContent = config.credFile;
     }
-    if (config.concurrency) {
-        slider.value = config.concurrency;
-        document.getElementById('concurrency-value').textContent = config.concurrency;
-    }
+    slider.value = config.concurrency || 0;
+    showConcurrency(slider.value);
     if (config.outputDir) {
         ocrOutputDir = config.outputDir;
     }
@@ -116,6 +121,12 @@ export async function initOCRTab(config) {
     if (config.mergePdf !== undefined) {
         document.getElementById('merge-pdf-check').checked = config.mergePdf;
     }
+    if (config.autoOrient !== undefined) {
+        document.getElementById('auto-orient-check').checked = config.autoOrient;
+    }
+    if (config.imageOnly !== undefined) {
+        document.getElementById('image-only-check').checked = config.imageOnly;
+    }
     if (config.scanMode) {
         const radio = document.querySelector(`input[name="scan-mode-ocr"][value="${config.scanMode}"]`);
         if (radio) radio.checked = true;
@@ -142,16 +153,36 @@ export async function initOCRTab(config) {
         const planRadio = document.querySelector(`input[name="ocrspace-plan"][value="${config.ocrSpacePlan}"]`);
         if (planRadio) planRadio.checked = true;
     }
+    if (config.ocrSpaceUrl) {
+        document.getElementById('ocrspace-url').value = config.ocrSpaceUrl;
+    }
     if (config.tesseractPath) {
         document.getElementById('tesseract-path-label').textContent = config.tesseractPath;
     }
+    document.getElementById('quota-fallback-select').value = config.quotaFallback || '';
+    (config.fallbacks || []).forEach(p => {
+        const cb = document.querySelector(`input[name="ocr-fallback"][value="${p}"]`);
+        if (cb) cb.checked = true;
+    });
+    (config.consensus || []).forEach(p => {
+        const cb = document.querySelector(`input[name="ocr-consensus"][value="${p}"]`);
+        if (cb) cb.checked = true;
+    });
+    document.getElementById('escalate-select').value = config.escalate || '';
+    document.getElementById('vision-auth-select').value = config.visionAuth || 'file';
+    if (config.visionApiKey) {
+        document.getElementById('vision-apikey').value = config.visionApiKey;
+    }
+    if (config.escalateBelow) {
+        document.getElementById('escalate-below').value = config.escalateBelow;
+    }
 
     // Restore last used imageDir from config and auto-load images
     if (config.imageDir) {
         ocrImageDir = config.imageDir;
         try {
             const app = await getApp();
-            ocrImages = await app.LoadImagesFromFolder(config.imageDir) || [];
+            ocrImages = await app.LoadOCRInputsFromFolder(config.imageDir) || [];
             document.getElementById('ocr-image-dir-label').textContent = config.imageDir + ' (' + ocrImages.length + ')';
             if (ocrImages.length > 0) renderOCRImageList(ocrImages);
             // Auto-set output dir if not manually set
@@ -184,6 +215,7 @@ export async function initOCRTab(config) {
 
     // Set up event listeners from Go
     setupOCREvents();
+    loadQueue();
 }
 
 async function populateLanguages(selectedLangs) {
@@ -221,9 +253,12 @@ async function setupOCREvents() {
             appendLog(data);
         });
 
+        runtime.EventsOn('queue:changed', (jobs) => {
+            renderQueue(jobs || []);
+        });
+
         runtime.EventsOn('ocr:finished', () => {
-            document.getElementById('start-ocr-btn').disabled = false;
-            document.getElementById('stop-ocr-btn').disabled = true;
+            setOCRRunning(false);
             appendLog({ message: t('msg.processingComplete'), isError: false, filename: '' });
 
             // Stop timer
@@ -307,7 +342,7 @@ async function selectImageDir() {
         ocrImageDir = dir;
 
         // Load images and render list
-        ocrImages = await app.LoadImagesFromFolder(dir) || [];
+        ocrImages = await app.LoadOCRInputsFromFolder(dir) || [];
         document.getElementById('ocr-image-dir-label').textContent = dir + ' (' + ocrImages.length + ')';
         renderOCRImageList(ocrImages);
         switchOCRSubtab('ocr-preview');
@@ -389,42 +424,90 @@ function gatherSettings() {
         ocrSpaceApiKey: document.getElementById('ocrspace-apikey').value.trim(),
         ocrSpaceEngine: parseInt(document.getElementById('ocrspace-engine').value) || 1,
         ocrSpacePlan: getSelectedPlan(),
+        ocrSpaceUrl: document.getElementById('ocrspace-url').value.trim(),
         tesseractPath: document.getElementById('tesseract-path-label').textContent,
         selectedFiles: selectedFiles,
+        autoOrient: document.getElementById('auto-orient-check').checked,
+        imageOnly: document.getElementById('image-only-check').checked,
+        quotaFallback: document.getElementById('quota-fallback-select').value,
+        fallbacks: getCheckedProviders('ocr-fallback'),
+        consensus: getCheckedProviders('ocr-consensus'),
+        escalate: document.getElementById('escalate-select').value,
+        escalateBelow: parseInt(document.getElementById('escalate-below').value) || 0,
+        visionAuth: document.getElementById('vision-auth-select').value,
+        visionApiKey: document.getElementById('vision-apikey').value.trim(),
     };
 }
 
-async function startOCR() {
+// getCheckedProviders returns the providers checked in a group (fallback or
+// consensus engines) in order (Google, OCR.space, Tesseract), leaving out
+// the selected provider
+function getCheckedProviders(name) {
+    const provider = getSelectedProvider();
+    return Array.from(document.querySelectorAll(`input[name="${name}"]:checked`))
+        .map(cb => cb.value)
+        .filter(p => p !== provider);
+}
+
+// prepareOCRSettings validates the form, fills in the default output folder
+// and saves the config. Returns null when something is missing. With
+// render=true no provider settings are needed.
+async function prepareOCRSettings(render) {
     const settings = gatherSettings();
 
     // Validate
     if (!settings.imageDir || settings.imageDir.startsWith('\uFF08') || settings.imageDir === t('placeholder.notSelected')) {
         showOCRError(t('msg.selectImageDir'));
-        return;
+        return null;
     }
-    if (settings.provider === 'ocrspace') {
+    // Images-to-PDF mode and re-rendering never call a provider
+    if (settings.imageOnly || render) {
+        // no provider settings needed
+    } else if (settings.provider === 'ocrspace') {
         if (!settings.ocrSpaceApiKey) {
             showOCRError(t('msg.enterApiKey'));
-            return;
+            return null;
         }
     } else if (settings.provider === 'tesseract') {
         if (!settings.tesseractPath || settings.tesseractPath.startsWith('\uFF08') || settings.tesseractPath === t('placeholder.notSelected')) {
             showOCRError(t('msg.selectTesseractPath'));
-            return;
+            return null;
         }
     } else {
-        if (!settings.credFile || settings.credFile.startsWith('\uFF08') || settings.credFile === t('placeholder.notSelected')) {
+        if (settings.visionAuth === 'file' && (!settings.credFile || settings.credFile.startsWith('\uFF08') || settings.credFile === t('placeholder.notSelected'))) {
             showOCRError(t('msg.selectApiKey'));
-            return;
+            return null;
+        }
+        if (settings.visionAuth === 'apikey' && !settings.visionApiKey) {
+            showOCRError(t('msg.enterVisionApiKey'));
+            return null;
         }
     }
-    if (settings.languages.length === 0) {
+    if (!settings.imageOnly && !render && settings.languages.length === 0) {
         showOCRError(t('msg.selectAtLeastOneLang'));
-        return;
+        return null;
+    }
+    // Fallback, consensus and escalation providers need their own provider settings
+    const notSelected = (v) => !v || v.startsWith('\uFF08') || v === t('placeholder.notSelected');
+    const fallbacks = settings.imageOnly || render ? [] : [...settings.fallbacks, ...settings.consensus, settings.quotaFallback, settings.escalate];
+    if (fallbacks.includes('ocrspace') && !settings.ocrSpaceApiKey) {
+        showOCRError(t('msg.enterApiKey'));
+        return null;
+    }
+    if (fallbacks.includes('google') && settings.visionAuth === 'file' && notSelected(settings.credFile)) {
+        showOCRError(t('msg.selectApiKey'));
+        return null;
+    }
+    if (fallbacks.includes('google') && settings.visionAuth === 'apikey' && !settings.visionApiKey) {
+        showOCRError(t('msg.enterVisionApiKey'));
+        return null;
+    }
+    if (fallbacks.includes('tesseract') && notSelected(settings.tesseractPath)) {
+        settings.tesseractPath = ''; // the backend detects it
     }
     if (settings.selectedFiles.length === 0) {
         showOCRError(t('msg.selectAtLeastOneImage'));
-        return;
+        return null;
     }
 
     // Auto-set output dir if empty
@@ -436,7 +519,7 @@ async function startOCR() {
             document.getElementById('ocr-output-dir-label').textContent = ocrOutputDir;
         } catch (e) {
             showOCRError(t('msg.cannotSetOutputDir') + e);
-            return;
+            return null;
         }
     }
 
@@ -455,19 +538,52 @@ async function startOCR() {
         config.ocrSpaceApiKey = settings.ocrSpaceApiKey;
         config.ocrSpaceEngine = settings.ocrSpaceEngine;
         config.ocrSpacePlan = settings.ocrSpacePlan;
+        config.ocrSpaceUrl = settings.ocrSpaceUrl;
         config.tesseractPath = settings.tesseractPath;
         config.imageDir = settings.imageDir;
+        config.autoOrient = settings.autoOrient;
+        config.imageOnly = settings.imageOnly;
+        config.quotaFallback = settings.quotaFallback;
+        config.fallbacks = settings.fallbacks;
+        config.consensus = settings.consensus;
+        config.escalate = settings.escalate;
+        config.escalateBelow = settings.escalateBelow;
+        if (settings.visionAuth) {
+            config.visionAuth = settings.visionAuth; // "none" is set in config.json only
+        }
+        config.visionApiKey = settings.visionApiKey;
         await app.SaveConfig(config);
     } catch (e) {
         console.error('Failed to save config:', e);
     }
 
+    return settings;
+}
+
+// setOCRRunning enables the start buttons or the stop button
+function setOCRRunning(running) {
+    ['start-ocr-btn', 'retry-failed-btn', 'render-ocr-btn', 'start-queue-btn'].forEach(id => {
+        document.getElementById(id).disabled = running;
+    });
+    document.getElementById('stop-ocr-btn').disabled = !running;
+    document.getElementById('pause-ocr-btn').disabled = !running;
+    setPauseLabel(false);
+}
+
+// setPauseLabel shows Pause or Continue on the pause button
+function setPauseLabel(paused) {
+    const btn = document.getElementById('pause-ocr-btn');
+    btn.dataset.i18n = paused ? 'btn.continue' : 'btn.pause';
+    btn.textContent = t(btn.dataset.i18n);
+}
+
+// beginOCRRun resets the log, progress and timer for a new run
+function beginOCRRun() {
     // Clear UI
     document.getElementById('log-area').innerHTML = '';
     document.getElementById('progress-bar').style.width = '0%';
     document.getElementById('progress-text').textContent = '0 / 0';
-    document.getElementById('start-ocr-btn').disabled = true;
-    document.getElementById('stop-ocr-btn').disabled = false;
+    setOCRRunning(true);
 
     // Start timer
     ocrTimer.reset();
@@ -490,20 +606,41 @@ async function startOCR() {
 
     // Switch to log sub-tab
     switchOCRSubtab('ocr-log');
+}
+
+// startOCR runs OCR, or with render=true re-renders the outputs from the
+// stored OCR responses. retryFailed=true runs only the files that failed
+// before, with the provider currently selected.
+async function startOCR(render = false, retryFailed = false) {
+    const settings = await prepareOCRSettings(render);
+    if (!settings) return;
+    settings.retryFailed = retryFailed;
+    beginOCRRun();
 
     // Start OCR
     try {
         const app = await getApp();
-        const result = await app.StartOCR(settings);
+        const result = render ? await app.StartRender(settings) : await app.StartOCR(settings);
         if (result) {
             showOCRError(result);
-            document.getElementById('start-ocr-btn').disabled = false;
-            document.getElementById('stop-ocr-btn').disabled = true;
+            setOCRRunning(false);
         }
     } catch (e) {
         console.error('Failed to start OCR:', e);
-        document.getElementById('start-ocr-btn').disabled = false;
-        document.getElementById('stop-ocr-btn').disabled = true;
+        setOCRRunning(false);
+    }
+}
+
+// togglePauseOCR pauses the running job (files in flight still finish) or
+// continues it
+async function togglePauseOCR() {
+    try {
+        const app = await getApp();
+        const paused = !(await app.IsOCRPaused());
+        await app.SetOCRPaused(paused);
+        setPauseLabel(paused);
+    } catch (e) {
+        console.error('Failed to pause OCR:', e);
     }
 }
 
@@ -512,12 +649,26 @@ async function stopOCR() {
         const app = await getApp();
         await app.StopOCR();
         document.getElementById('stop-ocr-btn').disabled = true;
+        document.getElementById('pause-ocr-btn').disabled = true;
     } catch (e) {
         console.error('Failed to stop OCR:', e);
     }
 }
 
 // Resume from session
+// showConcurrency labels the slider value; 0 lets the backend pick the
+// worker count (CPU count for Tesseract)
+function showConcurrency(value) {
+    const label = document.getElementById('concurrency-value');
+    if (parseInt(value) === 0) {
+        label.setAttribute('data-i18n', 'label.auto');
+        label.textContent = t('label.auto');
+    } else {
+        label.removeAttribute('data-i18n');
+        label.textContent = value;
+    }
+}
+
 export async function resumeOCR(session) {
     ocrImageDir = session.imageDir;
     ocrOutputDir = session.outputDir;
@@ -526,10 +677,12 @@ export async function resumeOCR(session) {
     document.getElementById('ocr-image-dir-label').textContent = session.imageDir;
     document.getElementById('ocr-output-dir-label').textContent = session.outputDir;
     document.getElementById('ocr-cred-label').textContent = session.credFile;
-    document.getElementById('concurrency-slider').value = session.concurrency;
-    document.getElementById('concurrency-value').textContent = session.concurrency;
+    document.getElementById('concurrency-slider').value = session.concurrency || 0;
+    showConcurrency(session.concurrency || 0);
     document.getElementById('merge-pdf-check').checked = session.mergePdf;
     document.getElementById('merge-filename').value = session.mergeFilename;
+    document.getElementById('auto-orient-check').checked = !!session.autoOrient;
+    document.getElementById('image-only-check').checked = !!session.imageOnly;
 
     // Restore scan mode
     if (session.scanMode) {
@@ -579,6 +732,131 @@ export async function resumeOCR(session) {
     await startOCR();
 }
 
+// ===== OCR Job Queue =====
+
+// enqueueOCR adds the current folder and settings to the job queue
+async function enqueueOCR() {
+    const settings = await prepareOCRSettings(false);
+    if (!settings) return;
+    try {
+        const app = await getApp();
+        await app.EnqueueOCR(settings);
+        switchOCRSubtab('ocr-queue');
+    } catch (e) {
+        showOCRError(t('msg.cannotQueue') + e);
+    }
+}
+
+// estimateOCR shows what starting the run would cost, from the files still
+// to do, the price table and this month's usage
+async function estimateOCR() {
+    const label = document.getElementById('ocr-estimate');
+    label.textContent = '';
+    const settings = await prepareOCRSettings(false);
+    if (!settings) return;
+    try {
+        const app = await getApp();
+        const est = await app.EstimateOCR(settings);
+        label.textContent = est.summary;
+    } catch (e) {
+        showOCRError(t('msg.cannotEstimate') + e);
+    }
+}
+
+async function startQueue() {
+    beginOCRRun();
+    try {
+        const app = await getApp();
+        const result = await app.StartQueue();
+        if (result) {
+            showOCRError(result);
+            setOCRRunning(false);
+        }
+    } catch (e) {
+        console.error('Failed to start queue:', e);
+        setOCRRunning(false);
+    }
+}
+
+async function clearFinishedJobs() {
+    try {
+        const app = await getApp();
+        await app.ClearFinishedJobs();
+    } catch (e) {
+        console.error('Failed to clear queue:', e);
+    }
+}
+
+async function loadQueue() {
+    try {
+        const app = await getApp();
+        renderQueue(await app.GetQueue() || []);
+    } catch (e) {
+        console.error('Failed to load queue:', e);
+    }
+}
+
+// renderQueue lists the queued jobs in run order with move up / move down /
+// cancel buttons
+function renderQueue(jobs) {
+    const list = document.getElementById('queue-list');
+    list.innerHTML = '';
+    if (jobs.length === 0) {
+        const empty = document.createElement('div');
+        empty.className = 'queue-empty';
+        empty.textContent = t('msg.queueEmpty');
+        list.appendChild(empty);
+        return;
+    }
+
+    const call = async (fn) => {
+        try {
+            await fn(await getApp());
+        } catch (e) {
+            showOCRError(String(e));
+        }
+    };
+
+    jobs.forEach((job, idx) => {
+        const row = document.createElement('div');
+        row.className = 'queue-row';
+
+        const status = document.createElement('span');
+        status.className = `queue-status queue-${job.status}`;
+        status.textContent = t(`queue.${job.status}`);
+        if (job.error) status.title = job.error;
+
+        const text = document.createElement('span');
+        text.className = 'queue-text';
+        const files = job.settings.selectedFiles ? job.settings.selectedFiles.length : 0;
+        text.textContent = t('msg.queueJob', { dir: job.settings.imageDir, count: files });
+        text.title = job.settings.imageDir + ' \u2192 ' + job.settings.outputDir;
+
+        const upBtn = document.createElement('button');
+        upBtn.className = 'btn btn-sm btn-secondary';
+        upBtn.textContent = '\u2191';
+        upBtn.title = t('tooltip.moveUp');
+        upBtn.disabled = idx === 0;
+        upBtn.addEventListener('click', () => call(app => app.MoveQueuedJob(job.id, idx - 1)));
+
+        const downBtn = document.createElement('button');
+        downBtn.className = 'btn btn-sm btn-secondary';
+        downBtn.textContent = '\u2193';
+        downBtn.title = t('tooltip.moveDown');
+        downBtn.disabled = idx === jobs.length - 1;
+        downBtn.addEventListener('click', () => call(app => app.MoveQueuedJob(job.id, idx + 1)));
+
+        const cancelBtn = document.createElement('button');
+        cancelBtn.className = 'btn btn-sm btn-danger';
+        cancelBtn.textContent = t('btn.cancel');
+        cancelBtn.disabled = job.status !== 'pending' && job.status !== 'running';
+        cancelBtn.addEventListener('click', () => call(app => app.CancelQueuedJob(job.id)));
+
+        row.append(status, text, upBtn, downBtn, cancelBtn);
+        list.appendChild(row);
+    });
+}
+
 // ===== OCR Image List =====
 
 function renderOCRImageList(images) {
@@ -591,13 +869,14 @@ function renderOCRImageList(images) {
     bar.classList.toggle('visible', images.length > 0);
 
     images.forEach((img, idx) => {
+        const isPdf = img.originalName.toLowerCase().endsWith('.pdf');
         const item = document.createElement('div');
         item.className = 'image-item selected';
         item.innerHTML = `
             <div class="thumb-container" data-path="${img.originalPath}" data-idx="${idx}">
                 <span class="thumb-badge">${idx + 1}</span>
                 <input type="checkbox" class="ocr-thumb-checkbox" data-idx="${idx}" checked>
-                <div class="thumb-placeholder">${idx + 1}</div>
+                <div class="thumb-placeholder">${isPdf ? 'PDF' : idx + 1}</div>
             </div>
             <div class="image-info">
                 <span class="filename" title="${img.originalName}">${img.originalName}</span>
@@ -635,6 +914,8 @@ function renderOCRImageList(images) {
     // Load thumbnails in batches
     const thumbItems = [];
     list.querySelectorAll('.thumb-container').forEach(container => {
+        // PDFs have no thumbnail; they keep the "PDF" placeholder
+        if (container.dataset.path.toLowerCase().endsWith('.pdf')) return;
         thumbItems.push({ container, path: container.dataset.path });
     });
     loadOCRThumbnailsBatched(thumbItems);
@@ -694,6 +975,7 @@ function ocrTogglePreview(e) {
     const thumbContainer = e.currentTarget;
     const path = thumbContainer.dataset.path;
     const preview = document.getElementById('hover-preview');
+    if (path.toLowerCase().endsWith('.pdf')) return;
 
     if (!preview.classList.contains('hidden') && ocrActivePreviewPath === path) {
         ocrClosePreview();
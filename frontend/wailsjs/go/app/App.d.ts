@@ -2,7 +2,11 @@
 // This file is automatically generated. DO NOT EDIT
 import {app} from '../models';
 
-export function ClearSession():Promise<void>;
+export function CancelQueuedJob(arg1:string):Promise<void>;
+
+export function ClearFinishedJobs():Promise<void>;
+
+export function ClearSession(arg1:string):Promise<void>;
 
 export function ClearUsageStats():Promise<void>;
 
@@ -12,6 +16,10 @@ export function ComputeRenamePreviewSingle(arg1:Array<app.ImageInfo>,arg2:number
 
 export function DetectTesseract():Promise<string>;
 
+export function EnqueueOCR(arg1:app.OCRSettings):Promise<app.QueuedJob>;
+
+export function EstimateOCR(arg1:app.OCRSettings):Promise<app.OCREstimate>;
+
 export function ExecuteRename(arg1:string,arg2:Array<app.RenamePreview>):Promise<void>;
 
 export function GetAvailableLanguages():Promise<Array<app.LangOption>>;
@@ -24,14 +32,22 @@ export function GetImageMetadataList(arg1:string):Promise<Array<app.ImageMetadat
 
 export function GetImageThumbnail(arg1:string,arg2:number):Promise<string>;
 
-export function GetPendingSession():Promise<app.Session>;
+export function GetPendingSessions():Promise<Array<app.Session>>;
+
+export function GetQueue():Promise<Array<app.QueuedJob>>;
 
 export function GetUsageStats():Promise<app.UsageStats>;
 
+export function IsOCRPaused():Promise<boolean>;
+
 export function IsOCRRunning():Promise<boolean>;
 
 export function LoadImagesFromFolder(arg1:string):Promise<Array<app.ImageInfo>>;
 
+export function LoadOCRInputsFromFolder(arg1:string):Promise<Array<app.ImageInfo>>;
+
+export function MoveQueuedJob(arg1:string,arg2:number):Promise<void>;
+
 export function RecordApiCall(arg1:string,arg2:string):Promise<void>;
 
 export function SaveConfig(arg1:app.AppConfig):Promise<void>;
@@ -40,10 +56,16 @@ export function SelectDirectory(arg1:string,arg2:string):Promise<string>;
 
 export function SelectFile(arg1:string,arg2:string,arg3:string):Promise<string>;
 
-export function StartConvert(arg1:string,arg2:number):Promise<string>;
+export function SetOCRPaused(arg1:boolean):Promise<void>;
+
+export function StartConvert(arg1:string,arg2:app.ConvertOptions):Promise<string>;
 
 export function StartOCR(arg1:app.OCRSettings):Promise<string>;
 
+export function StartQueue():Promise<string>;
+
+export function StartRender(arg1:app.OCRSettings):Promise<string>;
+
 export function StopConvert():Promise<void>;
 
 export function StopOCR():Promise<void>;
@@ -2,8 +2,16 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function ClearSession() {
-  return window['go']['app']['App']['ClearSession']();
+export function CancelQueuedJob(arg1) {
+  return window['go']['app']['App']['CancelQueuedJob'](arg1);
+}
+
+export function ClearFinishedJobs() {
+  return window['go']['app']['App']['ClearFinishedJobs']();
+}
+
+export function ClearSession(arg1) {
+  return window['go']['app']['App']['ClearSession'](arg1);
 }
 
 export function ClearUsageStats() {
@@ -22,6 +30,14 @@ export function DetectTesseract() {
   return window['go']['app']['App']['DetectTesseract']();
 }
 
+export function EnqueueOCR(arg1) {
+  return window['go']['app']['App']['EnqueueOCR'](arg1);
+}
+
+export function EstimateOCR(arg1) {
+  return window['go']['app']['App']['EstimateOCR'](arg1);
+}
+
 export function ExecuteRename(arg1, arg2) {
   return window['go']['app']['App']['ExecuteRename'](arg1, arg2);
 }
@@ -46,14 +62,22 @@ export function GetImageThumbnail(arg1, arg2) {
   return window['go']['app']['App']['GetImageThumbnail'](arg1, arg2);
 }
 
-export function GetPendingSession() {
-  return window['go']['app']['App']['GetPendingSession']();
+export function GetPendingSessions() {
+  return window['go']['app']['App']['GetPendingSessions']();
+}
+
+export function GetQueue() {
+  return window['go']['app']['App']['GetQueue']();
 }
 
 export function GetUsageStats() {
   return window['go']['app']['App']['GetUsageStats']();
 }
 
+export function IsOCRPaused() {
+  return window['go']['app']['App']['IsOCRPaused']();
+}
+
 export function IsOCRRunning() {
   return window['go']['app']['App']['IsOCRRunning']();
 }
@@ -62,6 +86,14 @@ export function LoadImagesFromFolder(arg1) {
   return window['go']['app']['App']['LoadImagesFromFolder'](arg1);
 }
 
+export function LoadOCRInputsFromFolder(arg1) {
+  return window['go']['app']['App']['LoadOCRInputsFromFolder'](arg1);
+}
+
+export function MoveQueuedJob(arg1, arg2) {
+  return window['go']['app']['App']['MoveQueuedJob'](arg1, arg2);
+}
+
 export function RecordApiCall(arg1, arg2) {
   return window['go']['app']['App']['RecordApiCall'](arg1, arg2);
 }
@@ -78,6 +110,10 @@ export function SelectFile(arg1, arg2, arg3) {
   return window['go']['app']['App']['SelectFile'](arg1, arg2, arg3);
 }
 
+export function SetOCRPaused(arg1) {
+  return window['go']['app']['App']['SetOCRPaused'](arg1);
+}
+
 export function StartConvert(arg1, arg2) {
   return window['go']['app']['App']['StartConvert'](arg1, arg2);
 }
@@ -86,6 +122,14 @@ export function StartOCR(arg1) {
   return window['go']['app']['App']['StartOCR'](arg1);
 }
 
+export function StartQueue() {
+  return window['go']['app']['App']['StartQueue']();
+}
+
+export function StartRender(arg1) {
+  return window['go']['app']['App']['StartRender'](arg1);
+}
+
 export function StopConvert() {
   return window['go']['app']['App']['StopConvert']();
 }
@@ -16,6 +16,29 @@ export namespace app {
 	    ocrSpacePlan: string;
 	    tesseractPath: string;
 	    imageDir: string;
+	    autoOrient: boolean;
+	    imageOnly: boolean;
+	    cacheMaxMb: number;
+	    retries: number;
+	    retryBaseMs: number;
+	    retryMaxMs: number;
+	    providerLimits?: Record<string, ProviderLimit>;
+	    quotaBudgets?: Record<string, QuotaBudget>;
+	    quotaFallback?: string;
+	    fallbacks?: string[];
+	    pricing?: Record<string, ProviderPrice>;
+	    escalate?: string;
+	    escalateBelow?: number;
+	    wordList?: string;
+	    consensus?: string[];
+	    visionBatch?: number;
+	    visionEndpoint?: string;
+	    visionInsecure?: boolean;
+	    visionAuth?: string;
+	    visionApiKey?: string;
+	    ocrSpaceUrl?: string;
+	    ocrSpaceTimeoutSec?: number;
+	    ocrSpaceProxy?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppConfig(source);
@@ -38,6 +61,91 @@ export namespace app {
 	        this.ocrSpacePlan = source["ocrSpacePlan"];
 	        this.tesseractPath = source["tesseractPath"];
 	        this.imageDir = source["imageDir"];
+	        this.autoOrient = source["autoOrient"];
+	        this.imageOnly = source["imageOnly"];
+	        this.cacheMaxMb = source["cacheMaxMb"];
+	        this.retries = source["retries"];
+	        this.retryBaseMs = source["retryBaseMs"];
+	        this.retryMaxMs = source["retryMaxMs"];
+	        this.providerLimits = this.convertValues(source["providerLimits"], ProviderLimit, true);
+	        this.quotaBudgets = this.convertValues(source["quotaBudgets"], QuotaBudget, true);
+	        this.quotaFallback = source["quotaFallback"];
+	        this.fallbacks = source["fallbacks"];
+	        this.pricing = this.convertValues(source["pricing"], ProviderPrice, true);
+	        this.escalate = source["escalate"];
+	        this.escalateBelow = source["escalateBelow"];
+	        this.wordList = source["wordList"];
+	        this.consensus = source["consensus"];
+	        this.visionBatch = source["visionBatch"];
+	        this.visionEndpoint = source["visionEndpoint"];
+	        this.visionInsecure = source["visionInsecure"];
+	        this.visionAuth = source["visionAuth"];
+	        this.visionApiKey = source["visionApiKey"];
+	        this.ocrSpaceUrl = source["ocrSpaceUrl"];
+	        this.ocrSpaceTimeoutSec = source["ocrSpaceTimeoutSec"];
+	        this.ocrSpaceProxy = source["ocrSpaceProxy"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConvertOptions {
+	    percent: number;
+	    format: string;
+	    colorMode: string;
+	    quality: number;
+	    concurrency: number;
+	    memoryBudgetMb: number;
+	    autoOrient: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConvertOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.percent = source["percent"];
+	        this.format = source["format"];
+	        this.colorMode = source["colorMode"];
+	        this.quality = source["quality"];
+	        this.concurrency = source["concurrency"];
+	        this.memoryBudgetMb = source["memoryBudgetMb"];
+	        this.autoOrient = source["autoOrient"];
+	    }
+	}
+	export class EngineEstimate {
+	    provider: string;
+	    role: string;
+	    calls: number;
+	    billable: number;
+	    cost: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new EngineEstimate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.role = source["role"];
+	        this.calls = source["calls"];
+	        this.billable = source["billable"];
+	        this.cost = source["cost"];
 	    }
 	}
 	export class ImageInfo {
@@ -94,6 +202,56 @@ export namespace app {
 	        this.code = source["code"];
 	    }
 	}
+	export class OCREstimate {
+	    provider: string;
+	    files: number;
+	    pages: number;
+	    perThousand: number;
+	    freeLeft: number;
+	    billable: number;
+	    cost: number;
+	    budgetLeft: number;
+	    summary: string;
+	    engines?: EngineEstimate[];
+	    maxCost: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OCREstimate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.files = source["files"];
+	        this.pages = source["pages"];
+	        this.perThousand = source["perThousand"];
+	        this.freeLeft = source["freeLeft"];
+	        this.billable = source["billable"];
+	        this.cost = source["cost"];
+	        this.budgetLeft = source["budgetLeft"];
+	        this.summary = source["summary"];
+	        this.engines = this.convertValues(source["engines"], EngineEstimate);
+	        this.maxCost = source["maxCost"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class OCRSettings {
 	    imageDir: string;
 	    outputDir: string;
@@ -109,6 +267,30 @@ export namespace app {
 	    ocrSpacePlan: string;
 	    tesseractPath: string;
 	    selectedFiles: string[];
+	    autoOrient: boolean;
+	    imageOnly: boolean;
+	    noCache: boolean;
+	    retryFailed: boolean;
+	    retries: number;
+	    retryBaseMs: number;
+	    retryMaxMs: number;
+	    ratePerSecond: number;
+	    ratePerMinute: number;
+	    ratePerHour: number;
+	    maxConcurrency: number;
+	    quotaFallback: string;
+	    fallbacks: string[];
+	    escalate: string;
+	    escalateBelow: number;
+	    consensus: string[];
+	    visionBatch: number;
+	    visionEndpoint: string;
+	    visionInsecure: boolean;
+	    visionAuth: string;
+	    visionApiKey: string;
+	    ocrSpaceUrl: string;
+	    ocrSpaceTimeoutSec: number;
+	    ocrSpaceProxy: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new OCRSettings(source);
@@ -130,6 +312,118 @@ export namespace app {
 	        this.ocrSpacePlan = source["ocrSpacePlan"];
 	        this.tesseractPath = source["tesseractPath"];
 	        this.selectedFiles = source["selectedFiles"];
+	        this.autoOrient = source["autoOrient"];
+	        this.imageOnly = source["imageOnly"];
+	        this.noCache = source["noCache"];
+	        this.retryFailed = source["retryFailed"];
+	        this.retries = source["retries"];
+	        this.retryBaseMs = source["retryBaseMs"];
+	        this.retryMaxMs = source["retryMaxMs"];
+	        this.ratePerSecond = source["ratePerSecond"];
+	        this.ratePerMinute = source["ratePerMinute"];
+	        this.ratePerHour = source["ratePerHour"];
+	        this.maxConcurrency = source["maxConcurrency"];
+	        this.quotaFallback = source["quotaFallback"];
+	        this.fallbacks = source["fallbacks"];
+	        this.escalate = source["escalate"];
+	        this.escalateBelow = source["escalateBelow"];
+	        this.consensus = source["consensus"];
+	        this.visionBatch = source["visionBatch"];
+	        this.visionEndpoint = source["visionEndpoint"];
+	        this.visionInsecure = source["visionInsecure"];
+	        this.visionAuth = source["visionAuth"];
+	        this.visionApiKey = source["visionApiKey"];
+	        this.ocrSpaceUrl = source["ocrSpaceUrl"];
+	        this.ocrSpaceTimeoutSec = source["ocrSpaceTimeoutSec"];
+	        this.ocrSpaceProxy = source["ocrSpaceProxy"];
+	    }
+	}
+	export class ProviderLimit {
+	    perSecond?: number;
+	    perMinute?: number;
+	    perHour?: number;
+	    maxConcurrency?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderLimit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.perSecond = source["perSecond"];
+	        this.perMinute = source["perMinute"];
+	        this.perHour = source["perHour"];
+	        this.maxConcurrency = source["maxConcurrency"];
+	    }
+	}
+	export class ProviderPrice {
+	    perThousand: number;
+	    freeMonthly?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderPrice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.perThousand = source["perThousand"];
+	        this.freeMonthly = source["freeMonthly"];
+	    }
+	}
+	export class QueuedJob {
+	    id: string;
+	    settings: OCRSettings;
+	    status: string;
+	    error?: string;
+	    owner?: number;
+	    addedAt: string;
+	    finishedAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueuedJob(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.settings = this.convertValues(source["settings"], OCRSettings);
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.owner = source["owner"];
+	        this.addedAt = source["addedAt"];
+	        this.finishedAt = source["finishedAt"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QuotaBudget {
+	    daily?: number;
+	    monthly?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QuotaBudget(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.daily = source["daily"];
+	        this.monthly = source["monthly"];
 	    }
 	}
 	export class RenamePreview {
@@ -153,6 +447,8 @@ export namespace app {
 	    }
 	}
 	export class Session {
+	    jobId: string;
+	    updatedAt: string;
 	    imageDir: string;
 	    outputDir: string;
 	    credFile: string;
@@ -169,6 +465,8 @@ export namespace app {
 	    ocrSpacePlan: string;
 	    tesseractPath: string;
 	    selectedFiles: string[];
+	    autoOrient: boolean;
+	    imageOnly: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new Session(source);
@@ -176,6 +474,8 @@ export namespace app {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.jobId = source["jobId"];
+	        this.updatedAt = source["updatedAt"];
 	        this.imageDir = source["imageDir"];
 	        this.outputDir = source["outputDir"];
 	        this.credFile = source["credFile"];
@@ -192,6 +492,8 @@ export namespace app {
 	        this.ocrSpacePlan = source["ocrSpacePlan"];
 	        this.tesseractPath = source["tesseractPath"];
 	        this.selectedFiles = source["selectedFiles"];
+	        this.autoOrient = source["autoOrient"];
+	        this.imageOnly = source["imageOnly"];
 	    }
 	}
 	export class UsageRecord {
@@ -3,17 +3,22 @@ package app
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"book2ocr/internal/taskbar"
 
@@ -25,11 +30,24 @@ import (
 // App is the main application struct bound to Wails
 type App struct {
 	ctx            context.Context
+	gui            bool // started by Wails: events go to the window
 	config         AppConfig
-	session        *Session
+	sessions       map[string]*Session // interrupted OCR jobs by job ID
+	sessionsMu     sync.Mutex
+	queue          []*QueuedJob
+	queueMu        sync.Mutex
+	cancelJob      context.CancelFunc      // cancels the running queued job only
+	limiters       map[string]*rateLimiter // request rate per provider, shared by all runs
+	limitersMu     sync.Mutex
+	httpClients    map[string]*http.Client // OCR.space clients by timeout and proxy, shared by all runs
+	httpClientsMu  sync.Mutex
+	tesseracts     map[string]tesseractInstall // Tesseract installs by binary path, for cache keys
+	tesseractsMu   sync.Mutex
 	stats          UsageStats
 	statsMu        sync.Mutex
+	quotaPending   map[string]int // provider calls in flight by limit key, guarded by statsMu
 	cancelOCR      context.CancelFunc
+	resumeOCR      chan struct{} // non-nil while OCR is paused; closed on resume
 	cancelConvert  context.CancelFunc
 	ocrRunning     bool
 	convertRunning bool
@@ -38,6 +56,8 @@ type App struct {
 	onLog          func(entry LogEntry)
 	onProgress     func(update ProgressUpdate)
 	onFinished     func()
+	onQuota        func(status QuotaStatus) // CLI mode: a usage budget ran out
+	onEscalation   func(r EscalationReport) // CLI mode: the escalation report of a run
 }
 
 // NewApp creates a new App instance
@@ -50,8 +70,10 @@ func NewApp() *App {
 // Startup is called when the app starts
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	a.gui = true
 	a.loadConfig()
-	a.loadSession()
+	a.loadSessions()
+	a.loadQueue()
 	a.loadStats()
 	taskbar.Init()
 }
@@ -70,7 +92,13 @@ func (a *App) configPath() string {
 	return filepath.Join(a.exeDir(), "config.json")
 }
 
-func (a *App) sessionPath() string {
+// sessionsDir holds one session file per interrupted OCR job
+func (a *App) sessionsDir() string {
+	return filepath.Join(a.exeDir(), "sessions")
+}
+
+// legacySessionPath is the single session file used before per-job sessions
+func (a *App) legacySessionPath() string {
 	return filepath.Join(a.exeDir(), "session.json")
 }
 
@@ -81,7 +109,7 @@ func (a *App) loadConfig() {
 	if err != nil {
 		a.config = AppConfig{
 			Languages:     []string{"en"},
-			Concurrency:   5,
+			Concurrency:   0,
 			MergePDF:      true,
 			MergeFilename: "Merge.pdf",
 			Theme:         "dark",
@@ -93,7 +121,7 @@ func (a *App) loadConfig() {
 	if err := json.Unmarshal(data, &a.config); err != nil {
 		a.config = AppConfig{
 			Languages:     []string{"en"},
-			Concurrency:   5,
+			Concurrency:   0,
 			MergePDF:      true,
 			MergeFilename: "Merge.pdf",
 			Theme:         "dark",
@@ -120,36 +148,100 @@ func (a *App) SaveConfig(cfg AppConfig) error {
 
 // --- Session management ---
 
-func (a *App) loadSession() {
-	data, err := os.ReadFile(a.sessionPath())
+// sessionJobID identifies an OCR job by its image and output folders, so
+// each book resumes from its own session whatever ran in between
+func sessionJobID(imageDir, outputDir string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(imageDir) + "\x00" + filepath.Clean(outputDir)))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (a *App) loadSessions() {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	a.sessions = make(map[string]*Session)
+
+	entries, _ := os.ReadDir(a.sessionsDir())
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(a.sessionsDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		var s Session
+		if err := json.Unmarshal(data, &s); err != nil || s.JobID == "" {
+			continue
+		}
+		a.sessions[s.JobID] = &s
+	}
+
+	// Move a session.json from an older version into the sessions folder
+	data, err := os.ReadFile(a.legacySessionPath())
 	if err != nil {
 		return
 	}
 	var s Session
-	if err := json.Unmarshal(data, &s); err != nil {
-		return
+	if err := json.Unmarshal(data, &s); err == nil && s.ImageDir != "" {
+		s.JobID = sessionJobID(s.ImageDir, s.OutputDir)
+		if _, exists := a.sessions[s.JobID]; !exists {
+			a.sessions[s.JobID] = &s
+			a.writeSession(&s)
+		}
+	}
+	os.Remove(a.legacySessionPath())
+}
+
+// GetPendingSessions returns every interrupted OCR job, most recent first
+func (a *App) GetPendingSessions() []Session {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	list := make([]Session, 0, len(a.sessions))
+	for _, s := range a.sessions {
+		list = append(list, *s)
 	}
-	a.session = &s
+	sort.Slice(list, func(i, j int) bool { return list[i].UpdatedAt > list[j].UpdatedAt })
+	return list
 }
 
-// GetPendingSession returns the pending session if any
-func (a *App) GetPendingSession() *Session {
-	return a.session
+// ClearSession removes the session of one job
+func (a *App) ClearSession(jobID string) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	delete(a.sessions, jobID)
+	os.Remove(filepath.Join(a.sessionsDir(), jobID+".json"))
 }
 
-// ClearSession removes the session file
-func (a *App) ClearSession() {
-	a.session = nil
-	os.Remove(a.sessionPath())
+// sessionFor returns the session of the job that OCRs imageDir into
+// outputDir, or nil
+func (a *App) sessionFor(imageDir, outputDir string) *Session {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	return a.sessions[sessionJobID(imageDir, outputDir)]
 }
 
+// saveSession stores a snapshot of s; the pipeline keeps appending to its
+// own copy.
 func (a *App) saveSession(s *Session) {
-	a.session = s
+	snapshot := *s
+	snapshot.ProcessedFiles = append([]string(nil), s.ProcessedFiles...)
+	snapshot.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	a.sessions[snapshot.JobID] = &snapshot
+	a.writeSession(&snapshot)
+}
+
+func (a *App) writeSession(s *Session) {
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return
 	}
-	os.WriteFile(a.sessionPath(), data, 0644)
+	if err := os.MkdirAll(a.sessionsDir(), 0755); err != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(a.sessionsDir(), s.JobID+".json"), data, 0644)
 }
 
 // --- Native dialogs ---
@@ -211,25 +303,18 @@ func (a *App) GetImageThumbnail(path string, maxSize int) (string, error) {
 	}
 	defer f.Close()
 
-	orientation := 1
-	if ex, err := exif.Decode(f); err == nil {
-		if tag, err := ex.Get(exif.Orientation); err == nil {
-			if v, err := tag.Int(0); err == nil {
-				orientation = v
-			}
-		}
-	}
-	f.Seek(0, io.SeekStart)
+	orientation := readOrientation(f)
 
 	img, format, err := image.Decode(f)
 	if err != nil {
 		return "", fmt.Errorf("decode: %w", err)
 	}
 
-	img = applyOrientation(img, orientation)
-
+	// Orient after shrinking: the bounding box is square, so the result is the
+	// same and only the small thumbnail has to be rotated.
 	thumb := resize.Thumbnail(uint(maxSize), uint(maxSize), img, resize.Lanczos3)
 	img = nil
+	thumb = applyOrientation(thumb, orientation)
 
 	var buf bytes.Buffer
 	switch strings.ToLower(format) {
@@ -253,6 +338,21 @@ func (a *App) GetImageThumbnail(path string, maxSize int) (string, error) {
 
 // --- Image orientation helpers ---
 
+// readOrientation returns the EXIF orientation tag (1-8) of the image in r,
+// or 1 when there is none. r is rewound to the start afterwards.
+func readOrientation(r io.ReadSeeker) int {
+	orientation := 1
+	if ex, err := exif.Decode(r); err == nil {
+		if tag, err := ex.Get(exif.Orientation); err == nil {
+			if v, err := tag.Int(0); err == nil {
+				orientation = v
+			}
+		}
+	}
+	r.Seek(0, io.SeekStart)
+	return orientation
+}
+
 func applyOrientation(img image.Image, orientation int) image.Image {
 	switch orientation {
 	case 1:
@@ -264,11 +364,11 @@ func applyOrientation(img image.Image, orientation int) image.Image {
 	case 4:
 		return flipV(img)
 	case 5:
-		return flipH(rotate90CW(img))
+		return transformImage(img, opTranspose)
 	case 6:
 		return rotate90CW(img)
 	case 7:
-		return flipH(rotate90CCW(img))
+		return transformImage(img, opTransverse)
 	case 8:
 		return rotate90CCW(img)
 	default:
@@ -277,58 +377,23 @@ func applyOrientation(img image.Image, orientation int) image.Image {
 }
 
 func rotate90CW(img image.Image) image.Image {
-	b := img.Bounds()
-	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
-	for x := b.Min.X; x < b.Max.X; x++ {
-		for y := b.Min.Y; y < b.Max.Y; y++ {
-			dst.Set(b.Max.Y-1-y, x, img.At(x, y))
-		}
-	}
-	return dst
+	return transformImage(img, opRotate90CW)
 }
 
 func rotate90CCW(img image.Image) image.Image {
-	b := img.Bounds()
-	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
-	for x := b.Min.X; x < b.Max.X; x++ {
-		for y := b.Min.Y; y < b.Max.Y; y++ {
-			dst.Set(y, b.Max.X-1-x, img.At(x, y))
-		}
-	}
-	return dst
+	return transformImage(img, opRotate90CCW)
 }
 
 func rotate180(img image.Image) image.Image {
-	b := img.Bounds()
-	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
-	for x := b.Min.X; x < b.Max.X; x++ {
-		for y := b.Min.Y; y < b.Max.Y; y++ {
-			dst.Set(b.Max.X-1-x, b.Max.Y-1-y, img.At(x, y))
-		}
-	}
-	return dst
+	return transformImage(img, opRotate180)
 }
 
 func flipH(img image.Image) image.Image {
-	b := img.Bounds()
-	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
-	for x := b.Min.X; x < b.Max.X; x++ {
-		for y := b.Min.Y; y < b.Max.Y; y++ {
-			dst.Set(b.Max.X-1-x, y, img.At(x, y))
-		}
-	}
-	return dst
+	return transformImage(img, opFlipH)
 }
 
 func flipV(img image.Image) image.Image {
-	b := img.Bounds()
-	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
-	for x := b.Min.X; x < b.Max.X; x++ {
-		for y := b.Min.Y; y < b.Max.Y; y++ {
-			dst.Set(x, b.Max.Y-1-y, img.At(x, y))
-		}
-	}
-	return dst
+	return transformImage(img, opFlipV)
 }
 
 // --- Language options ---
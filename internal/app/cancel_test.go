@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cancelDeadline is how soon a provider call has to return after the run is
+// stopped; far below the provider timeouts (60s and 120s)
+const cancelDeadline = 2 * time.Second
+
+// writeTestPage writes a small stand-in page for calls that only upload or
+// pass on the file
+func writeTestPage(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "page.png")
+	if err := os.WriteFile(path, []byte("not really a png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCallOcrSpaceCancel(t *testing.T) {
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		close(started)
+		<-r.Context().Done() // hang until the client goes away
+	}))
+	defer srv.Close()
+
+	a := NewApp()
+	settings := OCRSettings{OcrSpaceURL: srv.URL, OcrSpaceApiKey: "test", Languages: []string{"en"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.callOcrSpace(ctx, writeTestPage(t), settings)
+		done <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the server")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(cancelDeadline):
+		t.Fatal("callOcrSpace did not return after cancel")
+	}
+}
+
+func TestCallTesseractCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in tesseract is a shell script")
+	}
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "pid")
+	// The stand-in records its PID and turns into a long sleep, like a
+	// tesseract stuck on a huge page
+	script := "#!/bin/sh\necho $$ > " + pidFile + "\nexec sleep 60\n"
+	tesseract := filepath.Join(dir, "tesseract")
+	if err := os.WriteFile(tesseract, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewApp()
+	settings := OCRSettings{TesseractPath: tesseract, Languages: []string{"en"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.callTesseract(ctx, writeTestPage(t), settings)
+		done <- err
+	}()
+
+	var pid int
+	for deadline := time.Now().Add(5 * time.Second); pid == 0; {
+		if data, err := os.ReadFile(pidFile); err == nil {
+			pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		}
+		if pid == 0 && time.Now().After(deadline) {
+			t.Fatal("stand-in tesseract never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(cancelDeadline):
+		t.Fatal("callTesseract did not return after cancel")
+	}
+	if processAlive(pid) {
+		t.Fatalf("tesseract process %d still running", pid)
+	}
+}
@@ -0,0 +1,257 @@
+package app
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+)
+
+// CCITT T.6 (Group 4) code tables. Terminating codes cover run lengths 0-63;
+// make-up codes cover multiples of 64 up to 2560 (entries 27+ are the
+// extended make-up codes shared by both colors).
+var (
+	g4WhiteTerm = parseG4Codes(
+		"00110101", "000111", "0111", "1000", "1011", "1100", "1110", "1111",
+		"10011", "10100", "00111", "01000", "001000", "000011", "110100", "110101",
+		"101010", "101011", "0100111", "0001100", "0001000", "0010111", "0000011", "0000100",
+		"0101000", "0101011", "0010011", "0100100", "0011000", "00000010", "00000011", "00011010",
+		"00011011", "00010010", "00010011", "00010100", "00010101", "00010110", "00010111", "00101000",
+		"00101001", "00101010", "00101011", "00101100", "00101101", "00000100", "00000101", "00001010",
+		"00001011", "01010010", "01010011", "01010100", "01010101", "00100100", "00100101", "01011000",
+		"01011001", "01011010", "01011011", "01001010", "01001011", "00110010", "00110011", "00110100",
+	)
+	g4WhiteMakeup = parseG4Codes(
+		"11011", "10010", "010111", "0110111", "00110110", "00110111", "01100100", "01100101",
+		"01101000", "01100111", "011001100", "011001101", "011010010", "011010011", "011010100", "011010101",
+		"011010110", "011010111", "011011000", "011011001", "011011010", "011011011", "010011000", "010011001",
+		"010011010", "011000", "010011011", "00000001000", "00000001100", "00000001101", "000000010010", "000000010011",
+		"000000010100", "000000010101", "000000010110", "000000010111", "000000011100", "000000011101", "000000011110", "000000011111",
+	)
+	g4BlackTerm = parseG4Codes(
+		"0000110111", "010", "11", "10", "011", "0011", "0010", "00011",
+		"000101", "000100", "0000100", "0000101", "0000111", "00000100", "00000111", "000011000",
+		"0000010111", "0000011000", "0000001000", "00001100111", "00001101000", "00001101100", "00000110111", "00000101000",
+		"00000010111", "00000011000", "000011001010", "000011001011", "000011001100", "000011001101", "000001101000", "000001101001",
+		"000001101010", "000001101011", "000011010010", "000011010011", "000011010100", "000011010101", "000011010110", "000011010111",
+		"000001101100", "000001101101", "000011011010", "000011011011", "000001010100", "000001010101", "000001010110", "000001010111",
+		"000001100100", "000001100101", "000001010010", "000001010011", "000000100100", "000000110111", "000000111000", "000000100111",
+		"000000101000", "000001011000", "000001011001", "000000101011", "000000101100", "000001011010", "000001100110", "000001100111",
+	)
+	g4BlackMakeup = parseG4Codes(
+		"0000001111", "000011001000", "000011001001", "000001011011", "000000110011", "000000110100", "000000110101", "0000001101100",
+		"0000001101101", "0000001001010", "0000001001011", "0000001001100", "0000001001101", "0000001110010", "0000001110011", "0000001110100",
+		"0000001110101", "0000001110110", "0000001110111", "0000001010010", "0000001010011", "0000001010100", "0000001010101", "0000001011010",
+		"0000001011011", "0000001100100", "0000001100101", "00000001000", "00000001100", "00000001101", "000000010010", "000000010011",
+		"000000010100", "000000010101", "000000010110", "000000010111", "000000011100", "000000011101", "000000011110", "000000011111",
+	)
+
+	g4Pass       = parseG4Code("0001")
+	g4Horizontal = parseG4Code("001")
+	g4EOL        = parseG4Code("000000000001")
+	// g4Vertical is indexed by (a1 - b1) + 3
+	g4Vertical = parseG4Codes("0000010", "000010", "010", "1", "011", "000011", "0000011")
+)
+
+type g4Code struct {
+	bits  uint32
+	nBits uint
+}
+
+func parseG4Code(s string) g4Code {
+	var c g4Code
+	for _, ch := range s {
+		c.bits <<= 1
+		if ch == '1' {
+			c.bits |= 1
+		}
+		c.nBits++
+	}
+	return c
+}
+
+func parseG4Codes(codes ...string) []g4Code {
+	out := make([]g4Code, len(codes))
+	for i, s := range codes {
+		out[i] = parseG4Code(s)
+	}
+	return out
+}
+
+// g4BitWriter packs codes MSB-first (TIFF FillOrder 1).
+type g4BitWriter struct {
+	buf   []byte
+	acc   uint32
+	nBits uint
+}
+
+func (w *g4BitWriter) write(c g4Code) {
+	w.acc = w.acc<<c.nBits | c.bits
+	w.nBits += c.nBits
+	for w.nBits >= 8 {
+		w.nBits -= 8
+		w.buf = append(w.buf, byte(w.acc>>w.nBits))
+	}
+	w.acc &= 1<<w.nBits - 1
+}
+
+func (w *g4BitWriter) flush() {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, byte(w.acc<<(8-w.nBits)))
+		w.acc, w.nBits = 0, 0
+	}
+}
+
+func (w *g4BitWriter) writeRun(black bool, n int) {
+	term, makeup := g4WhiteTerm, g4WhiteMakeup
+	if black {
+		term, makeup = g4BlackTerm, g4BlackMakeup
+	}
+	for n >= 2560 {
+		w.write(makeup[len(makeup)-1])
+		n -= 2560
+	}
+	if n >= 64 {
+		w.write(makeup[n/64-1])
+		n %= 64
+	}
+	w.write(term[n])
+}
+
+// g4NextChange returns the first changing element at or after start, i.e. the
+// first pixel whose color differs from the pixel to its left. The imaginary
+// pixel before the start of the line is white. Returns len(line) if none.
+func g4NextChange(line []byte, start int) int {
+	if start >= len(line) {
+		return len(line)
+	}
+	prev := byte(0)
+	if start > 0 {
+		prev = line[start-1]
+	}
+	for i := start; i < len(line); i++ {
+		if line[i] != prev {
+			return i
+		}
+	}
+	return len(line)
+}
+
+// encodeG4Row codes one line against its reference line (T.6 section 2.2).
+// Pixels are 0 for white and 1 for black.
+func encodeG4Row(w *g4BitWriter, ref, cur []byte) {
+	width := len(cur)
+	a0 := -1
+	color := byte(0)
+	for a0 < width {
+		a1 := g4NextChange(cur, a0+1)
+		b1 := g4NextChange(ref, a0+1)
+		if b1 < width && ref[b1] == color {
+			b1 = g4NextChange(ref, b1+1)
+		}
+		b2 := g4NextChange(ref, b1+1)
+
+		if b2 < a1 {
+			w.write(g4Pass)
+			a0 = b2
+			continue
+		}
+
+		if d := a1 - b1; d >= -3 && d <= 3 {
+			w.write(g4Vertical[d+3])
+			a0 = a1
+			color ^= 1
+			continue
+		}
+
+		a2 := g4NextChange(cur, a1+1)
+		start := a0
+		if start < 0 {
+			start = 0
+		}
+		w.write(g4Horizontal)
+		w.writeRun(color == 1, a1-start)
+		w.writeRun(color == 0, a2-a1)
+		a0 = a2
+	}
+}
+
+// encodeG4 compresses a bilevel image with CCITT Group 4. Pixels darker than
+// mid-gray are treated as black.
+func encodeG4(img *image.Gray) []byte {
+	b := img.Bounds()
+	width := b.Dx()
+	ref := make([]byte, width)
+	cur := make([]byte, width)
+	w := &g4BitWriter{buf: make([]byte, 0, width*b.Dy()/32)}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := 0; x < width; x++ {
+			if row[x] < 128 {
+				cur[x] = 1
+			} else {
+				cur[x] = 0
+			}
+		}
+		encodeG4Row(w, ref, cur)
+		ref, cur = cur, ref
+	}
+
+	// End of facsimile block
+	w.write(g4EOL)
+	w.write(g4EOL)
+	w.flush()
+	return w.buf
+}
+
+// writeG4TIFF writes img as a single-strip, little-endian bilevel TIFF with
+// CCITT Group 4 compression.
+func writeG4TIFF(out io.Writer, img *image.Gray) error {
+	data := encodeG4(img)
+	n := len(data)
+	if n%2 == 1 {
+		data = append(data, 0)
+	}
+
+	const (
+		dtShort = 3
+		dtLong  = 4
+	)
+	b := img.Bounds()
+	entries := []struct {
+		tag, typ uint16
+		value    uint32
+	}{
+		{256, dtLong, uint32(b.Dx())}, // ImageWidth
+		{257, dtLong, uint32(b.Dy())}, // ImageLength
+		{258, dtShort, 1},             // BitsPerSample
+		{259, dtShort, 4},             // Compression: CCITT T.6
+		{262, dtShort, 0},             // PhotometricInterpretation: WhiteIsZero
+		{273, dtLong, 8},              // StripOffsets
+		{277, dtShort, 1},             // SamplesPerPixel
+		{278, dtLong, uint32(b.Dy())}, // RowsPerStrip
+		{279, dtLong, uint32(n)},      // StripByteCounts
+	}
+
+	ifdOffset := uint32(8 + len(data))
+	buf := make([]byte, 0, int(ifdOffset)+2+len(entries)*12+4)
+	buf = append(buf, 'I', 'I', 42, 0)
+	buf = binary.LittleEndian.AppendUint32(buf, ifdOffset)
+	buf = append(buf, data...)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(entries)))
+	for _, e := range entries {
+		buf = binary.LittleEndian.AppendUint16(buf, e.tag)
+		buf = binary.LittleEndian.AppendUint16(buf, e.typ)
+		buf = binary.LittleEndian.AppendUint32(buf, 1)
+		if e.typ == dtShort {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(e.value))
+			buf = binary.LittleEndian.AppendUint16(buf, 0)
+		} else {
+			buf = binary.LittleEndian.AppendUint32(buf, e.value)
+		}
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, 0) // no next IFD
+
+	_, err := out.Write(buf)
+	return err
+}
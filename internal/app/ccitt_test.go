@@ -0,0 +1,97 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+// bilevelImage returns a w×h image whose pixels are 0 or 255 as set returns
+// true for black
+func bilevelImage(w, h int, black func(x, y int) bool) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !black(x, y) {
+				img.Pix[y*img.Stride+x] = 255
+			}
+		}
+	}
+	return img
+}
+
+// runsImage is made of black and white runs of random length up to maxRun,
+// which exercises the horizontal and makeup codes as well as the vertical
+// ones
+func runsImage(w, h, maxRun int, seed int64) *image.Gray {
+	r := rand.New(rand.NewSource(seed))
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride:][:w]
+		v := byte(255)
+		for x := 0; x < w; {
+			n := 1 + r.Intn(maxRun)
+			for ; n > 0 && x < w; n, x = n-1, x+1 {
+				row[x] = v
+			}
+			v ^= 255
+		}
+	}
+	return img
+}
+
+func TestWriteG4TIFFRoundTrip(t *testing.T) {
+	noise := rand.New(rand.NewSource(5))
+	tests := []struct {
+		name string
+		img  *image.Gray
+	}{
+		{"blank", bilevelImage(64, 16, func(x, y int) bool { return false })},
+		{"solid", bilevelImage(64, 16, func(x, y int) bool { return true })},
+		{"single pixel", bilevelImage(1, 1, func(x, y int) bool { return true })},
+		{"random", bilevelImage(203, 97, func(x, y int) bool { return noise.Intn(2) == 0 })},
+		{"stripes", bilevelImage(100, 40, func(x, y int) bool { return (x/3+y/5)%2 == 0 })},
+		{"short runs", runsImage(317, 60, 8, 6)},
+		{"long runs", runsImage(1201, 30, 600, 7)},
+		{"wider than 2560", runsImage(5121, 12, 6000, 8)},
+		{"solid wider than 2560", bilevelImage(6001, 3, func(x, y int) bool { return true })},
+		{"sub-image", runsImage(120, 80, 20, 9).SubImage(image.Rect(13, 7, 101, 60)).(*image.Gray)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeG4TIFF(&buf, tt.img); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := tiff.Decode(&buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if err := sameBilevel(tt.img, decoded); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// sameBilevel compares the black pixels of want and got
+func sameBilevel(want *image.Gray, got image.Image) error {
+	wb, gb := want.Bounds(), got.Bounds()
+	if wb.Dx() != gb.Dx() || wb.Dy() != gb.Dy() {
+		return fmt.Errorf("size %v, want %v", gb.Size(), wb.Size())
+	}
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			w := want.GrayAt(wb.Min.X+x, wb.Min.Y+y).Y < 128
+			r, _, _, _ := got.At(gb.Min.X+x, gb.Min.Y+y).RGBA()
+			if g := r < 0x8000; g != w {
+				return fmt.Errorf("pixel (%d,%d) black = %v, want %v", x, y, g, w)
+			}
+		}
+	}
+	return nil
+}
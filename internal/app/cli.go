@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +35,37 @@ type CLIEvent struct {
 	Processed  int     `json:"processed,omitempty"`
 	Errors     int     `json:"errors,omitempty"`
 	Elapsed    string  `json:"elapsed,omitempty"`
+	Key        string  `json:"key,omitempty"`
+	Size       int64   `json:"size,omitempty"`
+	LastUsed   string  `json:"lastUsed,omitempty"`
+	Entries    int     `json:"entries,omitempty"`
+	Removed    int     `json:"removed,omitempty"`
+	Bytes      int64   `json:"bytes,omitempty"`
+	MaxBytes   int64   `json:"maxBytes,omitempty"`
+	Dir        string  `json:"dir,omitempty"`
+	JobID      string  `json:"jobId,omitempty"`
+	Status     string  `json:"status,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	Class      string  `json:"class,omitempty"`
+	Time       string  `json:"time,omitempty"`
+	Period     string  `json:"period,omitempty"`
+	Used       int     `json:"used,omitempty"`
+	Limit      int     `json:"limit,omitempty"`
+	Action     string  `json:"action,omitempty"`
+	SwitchTo   string  `json:"switchTo,omitempty"`
+
+	// estimate and escalation events
+	Pages       int              `json:"pages,omitempty"`
+	PerThousand float64          `json:"perThousand,omitempty"`
+	FreeLeft    *int             `json:"freeLeft,omitempty"`
+	Billable    int              `json:"billable,omitempty"`
+	Cost        float64          `json:"cost,omitempty"`
+	BudgetLeft  *int             `json:"budgetLeft,omitempty"`
+	Below       int              `json:"below,omitempty"`
+	Escalated   int              `json:"escalated,omitempty"`
+	Saved       float64          `json:"saved,omitempty"`
+	MaxCost     float64          `json:"maxCost,omitempty"`
+	Engines     []EngineEstimate `json:"engines,omitempty"`
 }
 
 var (
@@ -46,17 +80,68 @@ func emitJSON(evt CLIEvent) {
 }
 
 // RunCLI runs the OCR pipeline in CLI mode and returns the exit code.
+// args[1] is the command: "ocr", or "render" to regenerate the outputs from
+// stored OCR responses without calling a provider.
 func RunCLI(args []string) int {
 	attachConsole()
 
-	fs := flag.NewFlagSet("ocr", flag.ContinueOnError)
+	render := args[1] == "render"
 
-	dir := fs.String("dir", "", "Image directory (required)")
+	// Build app and load config
+	a := &App{
+		thumbSem: make(chan struct{}, 2),
+	}
+	a.loadConfig()
+	a.loadSessions()
+	a.loadStats()
+
+	var estimate *bool
+	if !render {
+		estimate = new(bool)
+	}
+	settings, ok := parseCLISettings(a, args[1], args[2:], estimate)
+	if !ok {
+		return 1
+	}
+	if estimate != nil && *estimate {
+		est, err := a.EstimateOCR(settings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		emitJSON(estimateEvent(est, settings))
+		return 0
+	}
+
+	// Set up context with signal handling
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	a.ctx = ctx
+	a.ocrRunning = true // lets SIGTSTP/SIGCONT pause and resume the run
+	watchPauseSignals(ctx, a)
+
+	code, _ := runCLIJob(a, ctx, settings, render)
+	return code
+}
+
+// parseCLISettings parses the flags of the ocr and render commands into
+// OCRSettings, starting from the saved config. Errors are printed to stderr.
+// A non-nil estimate adds the --estimate flag and receives its value.
+func parseCLISettings(a *App, name string, args []string, estimate *bool) (OCRSettings, bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if estimate != nil {
+		fs.BoolVar(estimate, "estimate", false, "Print the cost estimate of the run and exit without running it")
+	}
+
+	dir := fs.String("dir", "", "Image directory (required unless --pdf is given)")
+	pdfFile := fs.String("pdf", "", "Image-only PDF to OCR into a searchable copy")
 	output := fs.String("output", "", "Output directory")
 	provider := fs.String("provider", "", "OCR provider: google, ocrspace, tesseract")
 	cred := fs.String("cred", "", "Google Vision credential JSON path")
 	lang := fs.String("lang", "", "Comma-separated language codes")
-	concurrency := fs.Int("concurrency", 0, "Concurrency 1-10")
+	concurrency := fs.Int("concurrency", 0, "Workers to start with, 0 = auto (CPU count for tesseract, else 5, within the provider's max concurrency)")
+	concurrencySet := false
+	maxConcurrency := fs.Int("max-concurrency", 0, "Ceiling the worker count ramps up to while requests succeed (default: config, or per provider)")
 	scanMode := fs.String("scan-mode", "", "dual or single")
 	merge := fs.Bool("merge", false, "Merge PDFs after OCR")
 	mergeSet := false
@@ -65,43 +150,87 @@ func RunCLI(args []string) int {
 	ocrspaceKey := fs.String("ocrspace-key", "", "OCR.space API key")
 	ocrspaceEngine := fs.Int("ocrspace-engine", 0, "OCR.space engine 1/2/3")
 	ocrspacePlan := fs.String("ocrspace-plan", "", "OCR.space plan: free or pro")
+	ocrspaceURL := fs.String("ocrspace-url", "", "OCR.space endpoint, e.g. a PRO endpoint or a local stand-in (default: config, or https://api.ocr.space/parse/image)")
+	ocrspaceTimeout := fs.Int("ocrspace-timeout", 0, "OCR.space request timeout in seconds (default: config, or 60)")
+	ocrspaceProxy := fs.String("ocrspace-proxy", "", "Proxy URL for OCR.space requests (default: config, or HTTPS_PROXY)")
+	noCache := fs.Bool("no-cache", false, "Call the provider even for input found in the OCR cache")
+	retryFailed := fs.Bool("retry-failed", false, "Only run the files that failed in earlier runs")
+	retries := fs.Int("retries", 0, "Retries of a transient provider error, 0 = none (default: config retries, or 3)")
+	retriesSet := false
+	retryBaseMs := fs.Int("retry-base-ms", 0, "First retry backoff in ms, doubled per retry (default: config, or 1000)")
+	retryMaxMs := fs.Int("retry-max-ms", 0, "Retry backoff limit in ms (default: config, or 30000)")
+	ratePerSecond := fs.Int("rate-per-second", 0, "Provider requests per second, 0 = no limit (default: config, or per provider)")
+	ratePerMinute := fs.Int("rate-per-minute", 0, "Provider requests per minute, 0 = no limit (default: config, or per provider)")
+	ratePerHour := fs.Int("rate-per-hour", 0, "Provider requests per hour, 0 = no limit (default: config, or per provider)")
+	rateSet := make(map[string]bool)
+	fallback := fs.String("fallback", "", "Comma-separated providers tried in order on a page the provider fails, e.g. ocrspace,tesseract; none = no fallback (default: config)")
+	quotaFallback := fs.String("quota-fallback", "", "Provider to switch to when a usage budget is used up; none = stop (default: config, or stop)")
+	escalate := fs.String("escalate", "", "Provider to OCR again the pages scored below --escalate-below, e.g. google; none = off (default: config)")
+	escalateBelow := fs.Int("escalate-below", 0, "Page quality score 1-100 under which a page is escalated (default: config, or 60)")
+	consensus := fs.String("consensus", "", "Comma-separated providers that also OCR every page, their words voted with the provider's, e.g. google,ocrspace; none = off (default: config)")
+	visionBatch := fs.Int("vision-batch", 0, "Images per Google Vision request, 1-16 (default: config, or 16)")
+	visionEndpoint := fs.String("vision-endpoint", "", "Google Vision host:port, e.g. localhost:8080 for an emulator (default: config, or the public API)")
+	visionInsecure := fs.Bool("vision-insecure", false, "Connect to --vision-endpoint without TLS")
+	visionAuth := fs.String("vision-auth", "", "Google Vision auth: file (--cred), adc, apikey or none (default: config, or file)")
+	visionKey := fs.String("vision-api-key", "", "Google Vision API key for --vision-auth apikey")
+	autoOrient := fs.Bool("auto-orient", false, "Detect and fix page rotation/skew before OCR")
+	autoOrientSet := false
+	noOCR := fs.Bool("no-ocr", false, "Build image PDFs (per page and merged) without OCR")
+	noOCRSet := false
 
 	// Track whether --merge was explicitly set
 	fs.Visit(func(f *flag.Flag) {})
 
-	if err := fs.Parse(args[2:]); err != nil {
+	if err := fs.Parse(args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return 1
+		return OCRSettings{}, false
 	}
 
-	// Check if --merge was explicitly provided
+	// Check which boolean flags were explicitly provided
 	fs.Visit(func(f *flag.Flag) {
-		if f.Name == "merge" {
+		switch f.Name {
+		case "merge":
 			mergeSet = true
+		case "auto-orient":
+			autoOrientSet = true
+		case "no-ocr":
+			noOCRSet = true
+		case "retries":
+			retriesSet = true
+		case "concurrency":
+			concurrencySet = true
+		case "rate-per-second", "rate-per-minute", "rate-per-hour":
+			rateSet[f.Name] = true
 		}
 	})
 
+	if *pdfFile != "" {
+		if info, err := os.Stat(*pdfFile); err != nil || info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: PDF not found: %s\n", *pdfFile)
+			return OCRSettings{}, false
+		}
+		if *dir == "" {
+			*dir = filepath.Dir(*pdfFile)
+		}
+	}
+	if *pdfFile != "" && *noOCR {
+		fmt.Fprintln(os.Stderr, "Error: --pdf cannot be combined with --no-ocr")
+		return OCRSettings{}, false
+	}
+
 	if *dir == "" {
-		fmt.Fprintln(os.Stderr, "Error: --dir is required")
+		fmt.Fprintln(os.Stderr, "Error: --dir or --pdf is required")
 		fs.Usage()
-		return 1
+		return OCRSettings{}, false
 	}
 
 	// Verify directory exists
 	info, err := os.Stat(*dir)
 	if err != nil || !info.IsDir() {
 		fmt.Fprintf(os.Stderr, "Error: directory not found: %s\n", *dir)
-		return 1
+		return OCRSettings{}, false
 	}
 
-	// Build app and load config
-	a := &App{
-		thumbSem: make(chan struct{}, 2),
-	}
-	a.loadConfig()
-	a.loadSession()
-	a.loadStats()
-
 	// Merge CLI flags with config defaults
 	settings := OCRSettings{
 		ImageDir:       *dir,
@@ -116,6 +245,18 @@ func RunCLI(args []string) int {
 		OcrSpaceApiKey: a.config.OcrSpaceApiKey,
 		OcrSpaceEngine: a.config.OcrSpaceEngine,
 		OcrSpacePlan:   a.config.OcrSpacePlan,
+		AutoOrient:     a.config.AutoOrient,
+		ImageOnly:      a.config.ImageOnly,
+		QuotaFallback:  a.config.QuotaFallback,
+		Fallbacks:      a.config.Fallbacks,
+		Escalate:       a.config.Escalate,
+		EscalateBelow:  a.config.EscalateBelow,
+		Consensus:      a.config.Consensus,
+		VisionBatch:    a.config.VisionBatch,
+		VisionEndpoint: a.config.VisionEndpoint,
+		VisionInsecure: a.config.VisionInsecure,
+		VisionAuth:     a.config.VisionAuth,
+		VisionApiKey:   a.config.VisionApiKey,
 	}
 
 	// CLI flags override config
@@ -128,8 +269,8 @@ func RunCLI(args []string) int {
 	if *lang != "" {
 		settings.Languages = strings.Split(*lang, ",")
 	}
-	if *concurrency > 0 {
-		settings.Concurrency = *concurrency
+	if concurrencySet {
+		settings.Concurrency = max(*concurrency, 0)
 	}
 	if *scanMode != "" {
 		settings.ScanMode = *scanMode
@@ -152,6 +293,140 @@ func RunCLI(args []string) int {
 	if *ocrspacePlan != "" {
 		settings.OcrSpacePlan = *ocrspacePlan
 	}
+	if *ocrspaceURL != "" {
+		if u, err := url.Parse(*ocrspaceURL); err != nil || u.Host == "" {
+			fmt.Fprintf(os.Stderr, "Error: invalid --ocrspace-url %q\n", *ocrspaceURL)
+			return OCRSettings{}, false
+		}
+		settings.OcrSpaceURL = *ocrspaceURL
+	}
+	if *ocrspaceTimeout < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --ocrspace-timeout must be positive\n")
+		return OCRSettings{}, false
+	}
+	settings.OcrSpaceTimeoutSec = *ocrspaceTimeout
+	if *ocrspaceProxy != "" {
+		settings.OcrSpaceProxy = *ocrspaceProxy
+	}
+	if autoOrientSet {
+		settings.AutoOrient = *autoOrient
+	}
+	if noOCRSet {
+		settings.ImageOnly = *noOCR
+	}
+	if *pdfFile != "" {
+		settings.ImageOnly = false
+	}
+	settings.NoCache = *noCache
+	settings.RetryFailed = *retryFailed
+	if retriesSet {
+		settings.Retries = *retries
+		if *retries <= 0 {
+			settings.Retries = -1
+		}
+	}
+	if *retryBaseMs > 0 {
+		settings.RetryBaseMs = *retryBaseMs
+	}
+	if *retryMaxMs > 0 {
+		settings.RetryMaxMs = *retryMaxMs
+	}
+	// A rate given as 0 turns that limit off, which the settings spell -1
+	rate := func(name string, v int) int {
+		switch {
+		case !rateSet[name]:
+			return 0
+		case v <= 0:
+			return -1
+		}
+		return v
+	}
+	settings.RatePerSecond = rate("rate-per-second", *ratePerSecond)
+	settings.RatePerMinute = rate("rate-per-minute", *ratePerMinute)
+	settings.RatePerHour = rate("rate-per-hour", *ratePerHour)
+	if *maxConcurrency > 0 {
+		settings.MaxConcurrency = *maxConcurrency
+	}
+	switch *fallback {
+	case "":
+	case "none":
+		settings.Fallbacks = nil
+	default:
+		settings.Fallbacks = nil
+		for _, p := range strings.Split(*fallback, ",") {
+			if !slices.Contains(ocrProviders, p) {
+				fmt.Fprintf(os.Stderr, "Error: unknown fallback provider %q (google, ocrspace, tesseract)\n", p)
+				return OCRSettings{}, false
+			}
+			settings.Fallbacks = append(settings.Fallbacks, p)
+		}
+	}
+	switch *quotaFallback {
+	case "":
+	case "none":
+		settings.QuotaFallback = ""
+	case "google", "ocrspace", "tesseract":
+		settings.QuotaFallback = *quotaFallback
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown quota fallback %q (google, ocrspace, tesseract, none)\n", *quotaFallback)
+		return OCRSettings{}, false
+	}
+
+	if *visionBatch < 0 || *visionBatch > visionBatchMax {
+		fmt.Fprintf(os.Stderr, "Error: --vision-batch must be between 1 and %d\n", visionBatchMax)
+		return OCRSettings{}, false
+	}
+	if *visionBatch > 0 {
+		settings.VisionBatch = *visionBatch
+	}
+	if *visionEndpoint != "" {
+		settings.VisionEndpoint = *visionEndpoint
+	}
+	if *visionInsecure {
+		settings.VisionInsecure = true
+	}
+	switch *visionAuth {
+	case "":
+	case visionAuthFile, visionAuthADC, visionAuthAPIKey, visionAuthNone:
+		settings.VisionAuth = *visionAuth
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown Google Vision auth %q (file, adc, apikey, none)\n", *visionAuth)
+		return OCRSettings{}, false
+	}
+	if *visionKey != "" {
+		settings.VisionApiKey = *visionKey
+	}
+	switch *consensus {
+	case "":
+	case "none":
+		settings.Consensus = nil
+	default:
+		settings.Consensus = nil
+		for _, p := range strings.Split(*consensus, ",") {
+			if !slices.Contains(ocrProviders, p) {
+				fmt.Fprintf(os.Stderr, "Error: unknown consensus provider %q (google, ocrspace, tesseract)\n", p)
+				return OCRSettings{}, false
+			}
+			settings.Consensus = append(settings.Consensus, p)
+		}
+	}
+	switch *escalate {
+	case "":
+	case "none":
+		settings.Escalate = ""
+	case "google", "ocrspace", "tesseract":
+		settings.Escalate = *escalate
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown escalation provider %q (google, ocrspace, tesseract, none)\n", *escalate)
+		return OCRSettings{}, false
+	}
+	if *escalateBelow < 0 || *escalateBelow > 100 {
+		fmt.Fprintf(os.Stderr, "Error: --escalate-below must be between 1 and 100\n")
+		return OCRSettings{}, false
+	}
+	if *escalateBelow > 0 {
+		settings.EscalateBelow = *escalateBelow
+	}
 
 	// Default scan mode
 	if settings.ScanMode == "" {
@@ -162,27 +437,31 @@ func RunCLI(args []string) int {
 		settings.Provider = "google"
 	}
 
-	// Scan directory for matching image files
-	entries, err := os.ReadDir(*dir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading directory: %v\n", err)
-		return 1
-	}
-
 	var files []string
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
+	if *pdfFile != "" {
+		files = []string{*pdfFile}
+	} else {
+		// Scan directory for matching image files
+		entries, err := os.ReadDir(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading directory: %v\n", err)
+			return OCRSettings{}, false
 		}
-		if matchesOCRPattern(e.Name(), settings.ScanMode) {
-			files = append(files, filepath.Join(*dir, e.Name()))
+
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if matchesOCRPattern(e.Name(), settings.ScanMode) {
+				files = append(files, filepath.Join(*dir, e.Name()))
+			}
 		}
+		sort.Strings(files)
 	}
-	sort.Strings(files)
 
 	if len(files) == 0 {
 		fmt.Fprintln(os.Stderr, "Error: no matching image files found in directory")
-		return 1
+		return OCRSettings{}, false
 	}
 
 	settings.SelectedFiles = files
@@ -191,13 +470,20 @@ func RunCLI(args []string) int {
 	if *output != "" {
 		settings.OutputDir = *output
 	} else if settings.OutputDir == "" {
-		settings.OutputDir = filepath.Join(a.exeDir(), "output", filepath.Base(*dir))
+		name := filepath.Base(*dir)
+		if *pdfFile != "" {
+			name = strings.TrimSuffix(filepath.Base(*pdfFile), filepath.Ext(*pdfFile))
+		}
+		settings.OutputDir = filepath.Join(a.exeDir(), "output", name)
 	}
 
-	// Set up context with signal handling
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
-	a.ctx = ctx
+	return settings, true
+}
+
+// runCLIJob runs one OCR (or render) job, printing its events as JSON Lines,
+// and returns the exit code and the pipeline's error.
+func runCLIJob(a *App, ctx context.Context, settings OCRSettings, render bool) (int, error) {
+	files := settings.SelectedFiles
 
 	// Track errors and progress for exit code
 	var errorCount int   // per-file errors
@@ -205,25 +491,19 @@ func RunCLI(args []string) int {
 	var lastCurrent int  // last progress current value
 	var errorMu sync.Mutex
 
-	// Count remaining (after session skip)
-	processedSet := make(map[string]bool)
-	if a.session != nil && a.session.ImageDir == settings.ImageDir {
-		for _, f := range a.session.ProcessedFiles {
-			processedSet[f] = true
-		}
-	}
+	// Count remaining (after session skip), as the pipeline will
 	remaining := 0
-	for _, f := range files {
-		base := filepath.Base(f)
-		pdfName := strings.TrimSuffix(base, filepath.Ext(base)) + ".pdf"
-		outputPath := filepath.Join(settings.OutputDir, pdfName)
-		if processedSet[base] {
-			continue
-		}
-		if _, err := os.Stat(outputPath); err == nil {
-			continue
+	if render {
+		// Render redoes every file that has stored results
+		results := loadPageResults(settings.OutputDir)
+		for _, f := range files {
+			if len(results.forFile(filepath.Base(f))) > 0 {
+				remaining++
+			}
 		}
-		remaining++
+	} else {
+		plan := a.planOCRRun(settings, loadPageResults(settings.OutputDir), loadFailureLog(settings.OutputDir))
+		files, remaining = plan.files, len(plan.remaining)
 	}
 
 	// Emit start event
@@ -259,6 +539,30 @@ func RunCLI(args []string) int {
 		}
 	}
 
+	a.onQuota = func(q QuotaStatus) {
+		emitJSON(CLIEvent{
+			Type:     "quota",
+			Provider: q.Provider,
+			Period:   q.Period,
+			Used:     q.Used,
+			Limit:    q.Limit,
+			Action:   q.Action,
+			SwitchTo: q.SwitchTo,
+		})
+	}
+
+	a.onEscalation = func(r EscalationReport) {
+		emitJSON(CLIEvent{
+			Type:      "escalation",
+			Provider:  r.Provider,
+			Below:     r.Below,
+			Pages:     r.Pages,
+			Escalated: r.Escalated,
+			Cost:      r.Cost,
+			Saved:     r.Saved,
+		})
+	}
+
 	a.onProgress = func(update ProgressUpdate) {
 		emitJSON(CLIEvent{
 			Type:    "progress",
@@ -271,20 +575,41 @@ func RunCLI(args []string) int {
 		errorMu.Unlock()
 	}
 
-	// Run OCR pipeline synchronously
-	a.runOCRPipeline(ctx, settings)
+	// Run the pipeline synchronously
+	alreadyDone := len(files) - remaining
+	var runErr error
+	if render {
+		a.runRenderPipeline(ctx, settings)
+		alreadyDone = 0
+	} else {
+		runErr = a.runOCRPipeline(ctx, settings)
+	}
 
 	elapsed := time.Since(startTime).Round(time.Second).String()
 
 	errorMu.Lock()
 	finalErrors := errorCount
 	isFatal := fatalError
-	actualProcessed := lastCurrent - (len(files) - remaining) // subtract alreadyDone
+	actualProcessed := lastCurrent - alreadyDone
 	if actualProcessed < 0 {
 		actualProcessed = 0
 	}
 	errorMu.Unlock()
 
+	// Files still failed after this run, one event each
+	if !render {
+		for _, r := range loadFailureLog(settings.OutputDir).unresolved() {
+			emitJSON(CLIEvent{
+				Type:     "failure",
+				Filename: r.File,
+				Provider: r.Provider,
+				Class:    r.Class,
+				Error:    r.Error,
+				Time:     r.Time,
+			})
+		}
+	}
+
 	emitJSON(CLIEvent{
 		Type:      "done",
 		Processed: actualProcessed,
@@ -293,10 +618,240 @@ func RunCLI(args []string) int {
 	})
 
 	if isFatal {
-		return 1
+		return 1, runErr
 	}
 	if finalErrors > 0 {
-		return 2
+		return 2, runErr
+	}
+	return 0, runErr
+}
+
+// RunCacheCLI inspects and prunes the OCR cache and returns the exit code.
+//
+//	cache stats                      entry count and size
+//	cache list                       one line per entry, most recently used first
+//	cache prune [--max-mb N] [--older-than 30d]
+//	cache clear                      remove every entry
+func RunCacheCLI(args []string) int {
+	attachConsole()
+
+	if len(args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: cache stats|list|prune|clear")
+		return 1
+	}
+	action := args[2]
+
+	fs := flag.NewFlagSet("cache "+action, flag.ContinueOnError)
+	maxMB := fs.Int("max-mb", 0, "Size limit in MB (default: config cacheMaxMb, or 500)")
+	olderThan := fs.String("older-than", "", "Also remove entries unused for this long, e.g. 30d or 12h")
+	if err := fs.Parse(args[3:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	a := &App{}
+	a.loadConfig()
+	dir := a.ocrCacheDir()
+	maxBytes := a.ocrCacheMaxBytes()
+	if *maxMB > 0 {
+		maxBytes = int64(*maxMB) << 20
+	}
+
+	switch action {
+	case "stats", "list":
+		entries, err := listOCRCache(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		var total int64
+		for _, e := range entries {
+			total += e.Size
+			if action == "list" {
+				emitJSON(CLIEvent{
+					Type:     "cacheEntry",
+					Key:      e.Key,
+					Provider: e.Provider,
+					Size:     e.Size,
+					LastUsed: e.LastUsed.Format(time.RFC3339),
+				})
+			}
+		}
+		emitJSON(CLIEvent{Type: "cache", Dir: dir, Entries: len(entries), Bytes: total, MaxBytes: maxBytes})
+
+	case "prune", "clear":
+		var maxAge time.Duration
+		if action == "clear" {
+			maxBytes = 0
+		} else if *olderThan != "" {
+			d, err := parseAge(*olderThan)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --older-than: %v\n", err)
+				return 1
+			}
+			maxAge = d
+		}
+		removed, freed, err := pruneOCRCache(dir, maxBytes, maxAge)
+		emitJSON(CLIEvent{Type: "pruned", Dir: dir, Removed: removed, Bytes: freed})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown cache action %q (stats, list, prune, clear)\n", action)
+		return 1
 	}
 	return 0
 }
+
+// parseAge parses a Go duration, with "d" accepted for days
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// RunQueueCLI manages the OCR job queue and returns the exit code:
+// add (takes the ocr flags), list, run, move <id> <position>, cancel <id>
+// and clear (removes finished jobs).
+func RunQueueCLI(args []string) int {
+	attachConsole()
+
+	if len(args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: queue add|list|run|move|cancel|clear")
+		return 1
+	}
+	action := args[2]
+
+	a := &App{
+		thumbSem: make(chan struct{}, 2),
+	}
+	a.loadConfig()
+	a.loadSessions()
+	a.loadQueue()
+	a.loadStats()
+
+	switch action {
+	case "add":
+		settings, ok := parseCLISettings(a, "queue add", args[3:], nil)
+		if !ok {
+			return 1
+		}
+		job, err := a.EnqueueOCR(settings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		emitJSON(queuedJobEvent(job, len(a.GetQueue())))
+
+	case "list":
+		for i, job := range a.GetQueue() {
+			emitJSON(queuedJobEvent(job, i+1))
+		}
+
+	case "run":
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		a.ctx = ctx
+		a.ocrRunning = true // lets SIGTSTP/SIGCONT pause and resume the queue
+		a.onLog = func(entry LogEntry) {
+			emitJSON(CLIEvent{Type: "log", Message: entry.Message, IsError: entry.IsError})
+		}
+		watchPauseSignals(ctx, a)
+
+		code := 0
+		a.runQueue(ctx, func(jobCtx context.Context, settings OCRSettings) error {
+			jobCode, err := runCLIJob(a, jobCtx, settings, false)
+			if jobCode == 1 || code == 0 {
+				code = jobCode
+			}
+			return err
+		})
+		for i, job := range a.GetQueue() {
+			emitJSON(queuedJobEvent(job, i+1))
+		}
+		return code
+
+	case "move":
+		if len(args) < 5 {
+			fmt.Fprintln(os.Stderr, "Usage: queue move <job id> <position>")
+			return 1
+		}
+		pos, err := strconv.Atoi(args[4])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid position %q\n", args[4])
+			return 1
+		}
+		if err := a.MoveQueuedJob(args[3], pos-1); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+	case "cancel":
+		if len(args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: queue cancel <job id>")
+			return 1
+		}
+		if err := a.CancelQueuedJob(args[3]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+	case "clear":
+		if err := a.ClearFinishedJobs(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown queue action %q (add, list, run, move, cancel, clear)\n", action)
+		return 1
+	}
+	return 0
+}
+
+// estimateEvent is the CLI event of an estimate; counts that can be 0 and
+// still mean something are pointers, so that they are printed
+func estimateEvent(est OCREstimate, settings OCRSettings) CLIEvent {
+	evt := CLIEvent{
+		Type:        "estimate",
+		Provider:    est.Provider,
+		Remaining:   est.Files,
+		Pages:       est.Pages,
+		PerThousand: est.PerThousand,
+		Billable:    est.Billable,
+		Cost:        est.Cost,
+		MaxCost:     est.MaxCost,
+		Engines:     est.Engines,
+		Message:     est.Summary,
+		OutputDir:   settings.OutputDir,
+	}
+	if est.FreeLeft >= 0 {
+		evt.FreeLeft = &est.FreeLeft
+	}
+	if est.BudgetLeft >= 0 {
+		evt.BudgetLeft = &est.BudgetLeft
+	}
+	return evt
+}
+
+// queuedJobEvent describes a queued job at position (1-based)
+func queuedJobEvent(job QueuedJob, position int) CLIEvent {
+	return CLIEvent{
+		Type:       "job",
+		JobID:      job.ID,
+		Index:      position,
+		Status:     job.Status,
+		Dir:        job.Settings.ImageDir,
+		OutputDir:  job.Settings.OutputDir,
+		TotalFiles: len(job.Settings.SelectedFiles),
+		Provider:   job.Settings.Provider,
+		Error:      job.Error,
+	}
+}
@@ -2,5 +2,37 @@
 
 package app
 
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
 // attachConsole is a no-op on non-Windows platforms.
 func attachConsole() {}
+
+// watchPauseSignals pauses OCR on SIGTSTP and resumes it on SIGCONT until ctx
+// is done. SIGTSTP is caught, so the process keeps running and the files in
+// flight still finish.
+func watchPauseSignals(ctx context.Context, a *App) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTSTP, syscall.SIGCONT)
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s := <-sig:
+				paused := s == syscall.SIGTSTP
+				if paused != a.IsOCRPaused() {
+					a.SetOCRPaused(paused)
+					if paused {
+						a.emitOCRLog("", "Pausing: waiting for the files in flight", 0, 0, false)
+					}
+				}
+			}
+		}
+	}()
+}
@@ -2,7 +2,10 @@
 
 package app
 
-import "syscall"
+import (
+	"context"
+	"syscall"
+)
 
 // attachConsole re-attaches the parent process's console so that stdout/stderr
 // work when the GUI executable (built with -H windowsgui) is invoked from a
@@ -13,3 +16,6 @@ func attachConsole() {
 	const ATTACH_PARENT_PROCESS = ^uint32(0) // -1
 	proc.Call(uintptr(ATTACH_PARENT_PROCESS))
 }
+
+// watchPauseSignals does nothing on Windows, which has no SIGTSTP/SIGCONT.
+func watchPauseSignals(ctx context.Context, a *App) {}
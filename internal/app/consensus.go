@@ -0,0 +1,478 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	vision "cloud.google.com/go/vision/v2/apiv1"
+)
+
+// consensusProvider is recorded as the provider of a page whose text was
+// voted from several engines; PageResult.Engines lists them
+const consensusProvider = "consensus"
+
+// unknownConf is the vote of a word from a provider that reports no
+// confidence (OCR.space)
+const unknownConf = 0.5
+
+// reviewDir is the folder inside the OCR output folder that holds the fused
+// text of every consensus page the engines disagreed on, with the disputed
+// words marked as {{chosen|other}}
+const reviewDir = "review"
+
+// ocrWord is a recognized word with what separates it from the next one
+type ocrWord struct {
+	Text string
+	Conf float64 // 0-1, -1 when the provider reports none
+	Sep  string  // "", " ", "\n" or "\n\n"
+	Half int     // 1 or 2 for the left or right half of a dual scan, 0 = not known
+}
+
+// consensusEngines is the job's provider followed by the providers that
+// vote with it, each once, or nil when there is nothing to vote
+func consensusEngines(settings OCRSettings) []string {
+	engines := []string{providerName(settings.Provider)}
+	for _, p := range settings.Consensus {
+		if slices.Contains(ocrProviders, p) && !slices.Contains(engines, p) {
+			engines = append(engines, p)
+		}
+	}
+	if len(engines) < 2 {
+		return nil
+	}
+	return engines
+}
+
+// textWords splits plain text into words without confidences
+func textWords(text string) []ocrWord {
+	var words []ocrWord
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			if n := len(words); n > 0 {
+				words[n-1].Sep = "\n\n"
+			}
+			continue
+		}
+		for _, f := range fields {
+			words = append(words, ocrWord{Text: f, Conf: -1, Sep: " "})
+		}
+		words[len(words)-1].Sep = "\n"
+	}
+	return words
+}
+
+// isCJK reports whether r belongs to a script written without spaces
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// splitCJK splits Chinese and Japanese words into single characters, since
+// engines do not agree on where such words end
+func splitCJK(words []ocrWord) []ocrWord {
+	out := make([]ocrWord, 0, len(words))
+	for _, w := range words {
+		if !strings.ContainsFunc(w.Text, isCJK) {
+			out = append(out, w)
+			continue
+		}
+		var run []rune
+		flush := func() {
+			if len(run) > 0 {
+				out = append(out, ocrWord{Text: string(run), Conf: w.Conf, Half: w.Half})
+				run = nil
+			}
+		}
+		for _, r := range w.Text {
+			if isCJK(r) {
+				flush()
+				out = append(out, ocrWord{Text: string(r), Conf: w.Conf, Half: w.Half})
+			} else {
+				run = append(run, r)
+			}
+		}
+		flush()
+		out[len(out)-1].Sep = w.Sep
+	}
+	return out
+}
+
+// wordKey is what alignment compares: the word in lower case without
+// surrounding punctuation
+func wordKey(text string) string {
+	key := strings.TrimFunc(text, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) })
+	if key == "" {
+		return text
+	}
+	return strings.ToLower(key)
+}
+
+// alignWords adds the words of engine e to a word transition network, one
+// slot per aligned position holding each engine's word or nil. The
+// alignment is the edit distance between the slots and the words, a slot
+// matching when any of its words has the same key.
+func alignWords(slots [][]*ocrWord, words []ocrWord, e, engines int) [][]*ocrWord {
+	matches := func(slot []*ocrWord, w *ocrWord) bool {
+		key := wordKey(w.Text)
+		for _, o := range slot {
+			if o != nil && wordKey(o.Text) == key {
+				return true
+			}
+		}
+		return false
+	}
+	m, n := len(slots), len(words)
+	cost := make([][]int32, m+1)
+	for i := range cost {
+		cost[i] = make([]int32, n+1)
+		cost[i][0] = int32(i)
+	}
+	for j := 1; j <= n; j++ {
+		cost[0][j] = int32(j)
+	}
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			sub := cost[i-1][j-1] + 1
+			if matches(slots[i-1], &words[j-1]) {
+				sub--
+			}
+			cost[i][j] = min(sub, cost[i-1][j]+1, cost[i][j-1]+1)
+		}
+	}
+
+	var out [][]*ocrWord
+	i, j := m, n
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && cost[i][j] == cost[i-1][j-1]+1-boolInt32(matches(slots[i-1], &words[j-1])):
+			slot := slots[i-1]
+			slot[e] = &words[j-1]
+			out = append(out, slot)
+			i, j = i-1, j-1
+		case i > 0 && cost[i][j] == cost[i-1][j]+1:
+			out = append(out, slots[i-1])
+			i--
+		default:
+			slot := make([]*ocrWord, engines)
+			slot[e] = &words[j-1]
+			out = append(out, slot)
+			j--
+		}
+	}
+	slices.Reverse(out)
+	return out
+}
+
+func boolInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// textOut joins words with the strongest separator found between them
+type textOut struct {
+	sb  strings.Builder
+	sep string
+}
+
+func (t *textOut) add(text, sep string) {
+	if t.sb.Len() > 0 {
+		t.sb.WriteString(t.sep)
+	}
+	t.sb.WriteString(text)
+	t.sep = sep
+}
+
+// skip keeps the line or paragraph break of a word left out
+func (t *textOut) skip(sep string) {
+	if sepRank(sep) > sepRank(t.sep) {
+		t.sep = sep
+	}
+}
+
+// sepRank orders separators from none to a paragraph break
+func sepRank(sep string) int {
+	switch sep {
+	case "":
+		return 0
+	case " ":
+		return 1
+	case "\n":
+		return 2
+	}
+	return 3
+}
+
+// fusedPage is the text voted from several scans of one page
+type fusedPage struct {
+	scan          scanText
+	review        string // fused text with the disputed words marked
+	disagreements int
+}
+
+// fuseScans votes the words of several engines' scans of a page into one
+// text, ROVER-style: each engine's words are aligned to a word transition
+// network, and every slot goes to the word with the highest sum of
+// confidences. An engine that has no word in a slot votes for leaving it
+// out with its mean confidence on the page. Ties go to the earlier engine.
+// The text has no block coordinates, so a text layer is laid out without
+// them.
+func fuseScans(scans []scanText) fusedPage {
+	engines := len(scans)
+	means := make([]float64, engines)
+	var slots [][]*ocrWord
+	for e, scan := range scans {
+		words := splitCJK(scan.Words)
+		sum, n := 0.0, 0
+		for _, w := range words {
+			if w.Conf >= 0 {
+				sum += w.Conf
+				n++
+			}
+		}
+		means[e] = unknownConf
+		if n > 0 {
+			means[e] = sum / float64(n)
+		}
+		slots = alignWords(slots, words, e, engines)
+	}
+
+	var left, right, review textOut
+	var fused []ocrWord
+	var page fusedPage
+	confSum, confN := 0.0, 0
+	half := 1
+	for _, slot := range slots {
+		// Votes by text; "" is the vote for no word
+		votes := make(map[string]float64)
+		var order []string
+		keys := make(map[string]bool)
+		for e, w := range slot {
+			text, weight := "", means[e]
+			if w != nil {
+				text, weight = w.Text, w.Conf
+				if weight < 0 {
+					weight = unknownConf
+				}
+				if w.Half > 0 {
+					half = w.Half
+				}
+				keys[wordKey(w.Text)] = true
+			} else {
+				keys[""] = true
+			}
+			if _, ok := votes[text]; !ok {
+				order = append(order, text)
+			}
+			votes[text] += weight
+		}
+		best := order[0]
+		for _, text := range order[1:] {
+			if votes[text] > votes[best] {
+				best = text
+			}
+		}
+
+		// The separator comes from the winning word, or from any word of
+		// the slot when no word won
+		var win *ocrWord
+		for _, w := range slot {
+			if w != nil && (w.Text == best || best == "" && win == nil) {
+				if win == nil || w.Conf > win.Conf {
+					win = w
+				}
+			}
+		}
+		out := &left
+		if half == 2 {
+			out = &right
+		}
+
+		disputed := len(keys) > 1
+		mark := best
+		if disputed {
+			page.disagreements++
+			alts := []string{displayWord(best)}
+			for _, text := range order {
+				if text != best {
+					alts = append(alts, displayWord(text))
+				}
+			}
+			mark = "{{" + strings.Join(alts, "|") + "}}"
+		}
+		if best == "" {
+			// Only a disputed slot can vote for no word
+			out.skip(win.Sep)
+			review.add(mark, win.Sep)
+			continue
+		}
+		out.add(best, win.Sep)
+		review.add(mark, win.Sep)
+		fused = append(fused, ocrWord{Text: best, Conf: win.Conf, Sep: win.Sep, Half: win.Half})
+		if win.Conf >= 0 {
+			confSum += win.Conf
+			confN++
+		}
+	}
+
+	page.scan = scanText{Left: left.sb.String(), Right: right.sb.String(), Confidence: -1, Words: fused}
+	if confN > 0 {
+		page.scan.Confidence = confSum / float64(confN) * 100
+	}
+	if page.disagreements > 0 {
+		page.review = review.sb.String()
+	}
+	return page
+}
+
+// displayWord shows the vote for no word in the review text
+func displayWord(text string) string {
+	if text == "" {
+		return "∅"
+	}
+	return text
+}
+
+// reviewPath returns where the review text of page r is stored:
+// review/<file>[.p<page>].txt
+func reviewPath(outputDir string, r PageResult) string {
+	name := r.File
+	if r.Page > 0 {
+		name += fmt.Sprintf(".p%d", r.Page)
+	}
+	return filepath.Join(outputDir, reviewDir, name+".txt")
+}
+
+// saveReview writes the review text of page r, or removes an old one when
+// the engines agreed
+func saveReview(outputDir string, r PageResult, review string) error {
+	path := reviewPath(outputDir, r)
+	if review == "" {
+		os.Remove(path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("save review: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(review+"\n"), 0644); err != nil {
+		return fmt.Errorf("save review: %w", err)
+	}
+	return nil
+}
+
+// ocrConsensus OCRs one page with every engine and votes their words into
+// one text. An engine that fails is left out of the vote; with one engine
+// left its text is kept as it is, with none the last error is returned.
+func (a *App) ocrConsensus(ctx context.Context, client *vision.ImageAnnotatorClient, filePath string, page, pageCount int, settings OCRSettings, engines []string, result *PageResult) (scanText, error) {
+	var scans []scanText
+	var used []string
+	var budgetErrs []*budgetError
+	var lastErr error
+	first := *result
+	cached := true
+	for _, p := range engines {
+		s := settings
+		s.Provider = p
+		attempt := *result
+		attempt.Provider = p
+		scan, err := a.ocrPageWith(ctx, client, filePath, page, pageCount, s, &attempt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return scanText{}, err
+			}
+			var budgetErr *budgetError
+			if errors.As(err, &budgetErr) {
+				budgetErrs = append(budgetErrs, budgetErr)
+			} else {
+				a.emitOCRLog(result.File, fmt.Sprintf("%s: %s failed [%s], voting without it: %v",
+					pageResultLabel(*result), p, classifyOCRError(err), err), 0, 0, false)
+			}
+			lastErr = fmt.Errorf("%s: %w", p, err)
+			continue
+		}
+		if len(used) == 0 {
+			first = attempt
+		}
+		cached = cached && attempt.Cached
+		scans = append(scans, scan)
+		used = append(used, p)
+	}
+
+	if len(used) == 0 {
+		return scanText{}, lastErr
+	}
+	if pace := pacingFrom(ctx); pace != nil {
+		for _, e := range budgetErrs {
+			pace.quota.report(a, e, used[0])
+		}
+	}
+	*result = first
+	if len(used) == 1 {
+		return scans[0], nil
+	}
+
+	fused := fuseScans(scans)
+	result.Provider = consensusProvider
+	result.Engines = used
+	result.Cached = cached
+	result.Disagreements = fused.disagreements
+	if err := saveReview(settings.OutputDir, *result, fused.review); err != nil {
+		return scanText{}, err
+	}
+	return fused.scan, nil
+}
+
+// consensusSummary notes the consensus pages of a file for its log line
+func consensusSummary(pages []PageResult) string {
+	n, disputed := 0, 0
+	var engines []string
+	for _, r := range pages {
+		if r.Provider == consensusProvider {
+			n++
+			disputed += r.Disagreements
+			engines = r.Engines
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	msg := ", voted by " + strings.Join(engines, "+")
+	if disputed > 0 {
+		msg += fmt.Sprintf(", %d word(s) disputed (see %s/)", disputed, reviewDir)
+	}
+	return msg
+}
+
+// loadPageScan reads the text of page r from the stored responses; a
+// consensus page is voted again from its engines' responses
+func loadPageScan(outputDir string, r PageResult, scanMode string) (scanText, error) {
+	if r.Provider != consensusProvider {
+		data, err := loadRawResponse(outputDir, r)
+		if err != nil {
+			return scanText{}, err
+		}
+		return parseRawResponse(r.Provider, data, scanMode)
+	}
+	var scans []scanText
+	for _, p := range r.Engines {
+		er := r
+		er.Provider = p
+		data, err := loadRawResponse(outputDir, er)
+		if err != nil {
+			return scanText{}, err
+		}
+		scan, err := parseRawResponse(p, data, scanMode)
+		if err != nil {
+			return scanText{}, err
+		}
+		scans = append(scans, scan)
+	}
+	return fuseScans(scans).scan, nil
+}
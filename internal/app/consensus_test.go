@@ -0,0 +1,195 @@
+package app
+
+import (
+	"math"
+	"testing"
+)
+
+// scanWords splits text into words that all have confidence conf; -1 is an
+// engine that reports none
+func scanWords(text string, conf float64) []ocrWord {
+	words := textWords(text)
+	for i := range words {
+		words[i].Conf = conf
+	}
+	return words
+}
+
+// onHalf puts words on the left (1) or right (2) half of a dual scan
+func onHalf(words []ocrWord, half int) []ocrWord {
+	for i := range words {
+		words[i].Half = half
+	}
+	return words
+}
+
+func TestFuseScans(t *testing.T) {
+	tests := []struct {
+		name          string
+		scans         [][]ocrWord
+		left, right   string
+		disagreements int
+		review        string
+	}{
+		{
+			name:  "agreement",
+			scans: [][]ocrWord{scanWords("the quick fox", 0.9), scanWords("the quick fox", 0.8)},
+			left:  "the quick fox",
+		},
+		{
+			name:          "substituted word",
+			scans:         [][]ocrWord{scanWords("the quiek fox", 0.6), scanWords("the quick fox", 0.9)},
+			left:          "the quick fox",
+			disagreements: 1,
+			review:        "the {{quick|quiek}} fox",
+		},
+		{
+			// the second engine inserts x, which the first outvotes, and
+			// drops d, which the first keeps
+			name:          "inserted and deleted word",
+			scans:         [][]ocrWord{scanWords("a b c d", 0.9), scanWords("a x b c", 0.8)},
+			left:          "a b c d",
+			disagreements: 2,
+			review:        "a {{∅|x}} b c {{d|∅}}",
+		},
+		{
+			name:          "inserted word wins",
+			scans:         [][]ocrWord{scanWords("a b", 0.6), scanWords("a x b", 0.9)},
+			left:          "a x b",
+			disagreements: 1,
+			review:        "a {{x|∅}} b",
+		},
+		{
+			name:          "tie goes to the earlier engine",
+			scans:         [][]ocrWord{scanWords("a cat", 0.5), scanWords("a cot", 0.5)},
+			left:          "a cat",
+			disagreements: 1,
+			review:        "a {{cat|cot}}",
+		},
+		{
+			name:          "case and punctuation agree",
+			scans:         [][]ocrWord{scanWords("Hello, world", 0.9), scanWords("hello world", 0.8)},
+			left:          "Hello, world",
+			disagreements: 0,
+		},
+		{
+			// engines split CJK text differently; it is voted per character
+			name:          "CJK run",
+			scans:         [][]ocrWord{scanWords("日本語です", 0.9), scanWords("日本 話です", 0.7)},
+			left:          "日本語です",
+			disagreements: 1,
+			review:        "日本{{語|話}}です",
+		},
+		{
+			// an engine without confidences votes unknownConf per word
+			name:          "engine without confidences",
+			scans:         [][]ocrWord{scanWords("hello world", -1), scanWords("hello word", 0.4)},
+			left:          "hello world",
+			disagreements: 1,
+			review:        "hello {{world|word}}",
+		},
+		{
+			name: "dual scan halves",
+			scans: [][]ocrWord{
+				append(onHalf(scanWords("left page", 0.9), 1), onHalf(scanWords("right page", 0.9), 2)...),
+				append(onHalf(scanWords("left page", 0.8), 1), onHalf(scanWords("rigth page", 0.7), 2)...),
+			},
+			left:          "left page",
+			right:         "right page",
+			disagreements: 1,
+			review:        "left page\n{{right|rigth}} page",
+		},
+		{
+			name: "paragraph break kept",
+			scans: [][]ocrWord{
+				scanWords("one\n\ntwo", 0.9),
+				scanWords("one\n\ntwo", 0.9),
+			},
+			left: "one\n\ntwo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scans []scanText
+			for _, words := range tt.scans {
+				scans = append(scans, scanText{Words: words})
+			}
+			page := fuseScans(scans)
+			if page.scan.Left != tt.left || page.scan.Right != tt.right {
+				t.Errorf("text = %q / %q, want %q / %q", page.scan.Left, page.scan.Right, tt.left, tt.right)
+			}
+			if page.disagreements != tt.disagreements {
+				t.Errorf("disagreements = %d, want %d", page.disagreements, tt.disagreements)
+			}
+			if page.review != tt.review {
+				t.Errorf("review = %q, want %q", page.review, tt.review)
+			}
+		})
+	}
+}
+
+func TestFuseScansConfidence(t *testing.T) {
+	// "hello" is won by the engine reporting 0.4; "world" by the one
+	// reporting none, which does not count
+	page := fuseScans([]scanText{
+		{Words: scanWords("hello world", -1)},
+		{Words: scanWords("hello word", 0.4)},
+	})
+	if math.Abs(page.scan.Confidence-40) > 1e-9 {
+		t.Errorf("confidence = %v, want 40", page.scan.Confidence)
+	}
+
+	page = fuseScans([]scanText{
+		{Words: scanWords("a b", -1)},
+		{Words: scanWords("a b", -1)},
+	})
+	if page.scan.Confidence != -1 {
+		t.Errorf("confidence = %v without any, want -1", page.scan.Confidence)
+	}
+}
+
+func TestAlignWords(t *testing.T) {
+	a := scanWords("a b c d", 0.9)
+	b := scanWords("a x b c", 0.8)
+	slots := alignWords(nil, a, 0, 2)
+	slots = alignWords(slots, b, 1, 2)
+
+	want := [][2]string{{"a", "a"}, {"", "x"}, {"b", "b"}, {"c", "c"}, {"d", ""}}
+	if len(slots) != len(want) {
+		t.Fatalf("%d slots, want %d", len(slots), len(want))
+	}
+	for i, slot := range slots {
+		for e := range 2 {
+			got := ""
+			if slot[e] != nil {
+				got = slot[e].Text
+			}
+			if got != want[i][e] {
+				t.Errorf("slot %d engine %d = %q, want %q", i, e, got, want[i][e])
+			}
+		}
+	}
+}
+
+func TestSplitCJK(t *testing.T) {
+	words := []ocrWord{
+		{Text: "第3章は", Conf: 0.7, Sep: " ", Half: 2},
+		{Text: "Go", Conf: 0.9, Sep: "\n"},
+	}
+	got := splitCJK(words)
+	want := []ocrWord{
+		{Text: "第", Conf: 0.7, Half: 2},
+		{Text: "3", Conf: 0.7, Half: 2},
+		{Text: "章", Conf: 0.7, Half: 2},
+		{Text: "は", Conf: 0.7, Sep: " ", Half: 2},
+		{Text: "Go", Conf: 0.9, Sep: "\n"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("word %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -4,27 +4,50 @@ import (
 	"context"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/jpeg"
 	"image/png"
-	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	_ "image/gif"
 
 	"book2ocr/internal/taskbar"
 
 	"github.com/nfnt/resize"
-	"github.com/rwcarlsen/goexif/exif"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+	"golang.org/x/sync/semaphore"
 )
 
+// defaultConvertMemoryMB is the decode memory budget used when none is set
+const defaultConvertMemoryMB = 1024
+
 // imageExts shared set of supported image extensions
 var imageExts = map[string]bool{
 	".jpg": true, ".jpeg": true, ".png": true,
 	".tif": true, ".tiff": true, ".bmp": true,
+	".webp": true,
+}
+
+// convertFormatExts maps Convert output formats to file extensions
+var convertFormatExts = map[string]string{
+	"jpeg":    ".jpg",
+	"png":     ".png",
+	"tiff":    ".tif",
+	"tiff-g4": ".tif",
+	"webp":    ".webp",
+	"bmp":     ".bmp",
 }
 
 // GetImageMetadataList scans a directory and returns metadata without decoding pixels
@@ -82,8 +105,8 @@ func getImageDimensions(path string) (int, int) {
 	return cfg.Width, cfg.Height
 }
 
-// StartConvert begins resizing images in the given directory
-func (a *App) StartConvert(dir string, percent int) string {
+// StartConvert begins resizing/re-encoding images in the given directory
+func (a *App) StartConvert(dir string, opts ConvertOptions) string {
 	a.mu.Lock()
 	if a.convertRunning {
 		a.mu.Unlock()
@@ -104,7 +127,7 @@ func (a *App) StartConvert(dir string, percent int) string {
 			taskbar.SetProgress(0)
 			wailsRuntime.EventsEmit(a.ctx, "convert:finished", nil)
 		}()
-		a.runConvert(ctx, dir, percent)
+		a.runConvert(ctx, dir, opts)
 	}()
 
 	return ""
@@ -119,7 +142,7 @@ func (a *App) StopConvert() {
 	}
 }
 
-func (a *App) runConvert(ctx context.Context, dir string, percent int) {
+func (a *App) runConvert(ctx context.Context, dir string, opts ConvertOptions) {
 	emitLog := func(msg string, isError bool) {
 		wailsRuntime.EventsEmit(a.ctx, "convert:log", LogEntry{
 			Message: msg,
@@ -127,7 +150,17 @@ func (a *App) runConvert(ctx context.Context, dir string, percent int) {
 		})
 	}
 
+	// Workers finish out of order; an update behind the last one sent
+	// would move the bar backwards, so it is dropped
+	var progressMu sync.Mutex
+	lastProgress := 0
 	emitProgress := func(current, total int) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		if current <= lastProgress {
+			return
+		}
+		lastProgress = current
 		pct := float64(current) / float64(total)
 		wailsRuntime.EventsEmit(a.ctx, "convert:progress", ProgressUpdate{
 			Current: current,
@@ -137,10 +170,45 @@ func (a *App) runConvert(ctx context.Context, dir string, percent int) {
 		taskbar.SetProgress(pct * 100)
 	}
 
-	if percent < 1 || percent > 99 {
-		emitLog("百分比必須在 1-99 之間", true)
+	if opts.Percent < 1 || opts.Percent > 100 {
+		emitLog("百分比必須在 1-100 之間", true)
+		return
+	}
+	if opts.Format != "" && convertFormatExts[opts.Format] == "" {
+		emitLog(fmt.Sprintf("不支援的輸出格式: %s", opts.Format), true)
 		return
 	}
+	if opts.Percent == 100 && opts.Format == "" && opts.ColorMode == "" && !opts.AutoOrient {
+		emitLog("未選擇任何轉換（100% 且保留格式與色彩）", true)
+		return
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		opts.Quality = 92
+	}
+
+	// WebP is written when asked for and, keeping the format, for WebP
+	// sources; those then fail one by one when cwebp is missing
+	cwebpPath := ""
+	if opts.Format == "webp" || opts.Format == "" {
+		cwebpPath = a.detectCwebp()
+		if cwebpPath == "" && opts.Format == "webp" {
+			emitLog("找不到 cwebp，無法輸出 WebP", true)
+			return
+		}
+	}
+
+	// Orientation detector; nil when auto-orient is off
+	var orient func(image.Image) orientFix
+	if opts.AutoOrient {
+		tesseractPath := a.config.TesseractPath
+		if tesseractPath == "" {
+			tesseractPath = a.DetectTesseract()
+		}
+		languages := a.config.Languages
+		orient = func(img image.Image) orientFix {
+			return detectOrientation(ctx, img, tesseractPath, languages)
+		}
+	}
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -166,87 +234,381 @@ func (a *App) runConvert(ctx context.Context, dir string, percent int) {
 	}
 
 	total := len(files)
-	emitLog(fmt.Sprintf("開始轉檔 %d 張圖片，縮小至 %d%%", total, percent), false)
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+		if concurrency > 4 {
+			concurrency = 4
+		}
+	}
+	if concurrency > 16 {
+		concurrency = 16
+	}
+	budgetMB := opts.MemoryBudgetMB
+	if budgetMB < 1 {
+		budgetMB = defaultConvertMemoryMB
+	}
+	budget := int64(budgetMB) << 20
+
+	emitLog(fmt.Sprintf("開始轉檔 %d 張圖片，縮小至 %d%%（%d 個工作執行緒，記憶體上限 %d MB）",
+		total, opts.Percent, concurrency, budgetMB), false)
+
+	// Workers are bounded both by count and by the estimated decode footprint
+	// of the images they hold, so several 50 MP scans never decode at once.
+	sem := make(chan struct{}, concurrency)
+	mem := semaphore.NewWeighted(budget)
+	var wg sync.WaitGroup
+	var processed int64
+
+	for _, fp := range files {
+		weight := convertMemEstimate(fp)
+		if weight > budget {
+			weight = budget
+		}
 
-	for i, fp := range files {
 		select {
 		case <-ctx.Done():
+			wg.Wait()
 			emitLog("轉檔已停止", false)
 			return
-		default:
+		case sem <- struct{}{}:
 		}
-
-		baseName := filepath.Base(fp)
-		err := resizeOneImage(fp, percent)
-		if err != nil {
-			emitLog(fmt.Sprintf("[%s] 錯誤: %v", baseName, err), true)
-		} else {
-			emitLog(fmt.Sprintf("[%s] OK", baseName), false)
+		if err := mem.Acquire(ctx, weight); err != nil {
+			wg.Wait()
+			emitLog("轉檔已停止", false)
+			return
 		}
-		emitProgress(i+1, total)
+
+		wg.Add(1)
+		go func(fp string, weight int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer mem.Release(weight)
+
+			baseName := filepath.Base(fp)
+			outPath, fix, err := convertOneImage(fp, opts, cwebpPath, orient)
+			note := ""
+			if fix.needed() {
+				note = orientSummary(PageResult{Rotation: fix.Rotation, RotationSource: fix.Source, Skew: fix.Skew})
+			}
+			if err != nil {
+				emitLog(fmt.Sprintf("[%s] 錯誤: %v", baseName, err), true)
+			} else if outPath != fp {
+				emitLog(fmt.Sprintf("[%s] OK → %s%s", baseName, filepath.Base(outPath), note), false)
+			} else {
+				emitLog(fmt.Sprintf("[%s] OK%s", baseName, note), false)
+			}
+			emitProgress(int(atomic.AddInt64(&processed, 1)), total)
+		}(fp, weight)
 	}
 
+	wg.Wait()
+
 	emitLog(fmt.Sprintf("轉檔完成！共處理 %d 張圖片", total), false)
 }
 
-func resizeOneImage(filePath string, percent int) error {
+// convertMemEstimate approximates the peak memory needed to convert one image:
+// the decoded source plus the rotated and resized copies, at up to 4 bytes per
+// pixel each. Dimensions come from the header only.
+func convertMemEstimate(path string) int64 {
+	w, h := getImageDimensions(path)
+	if w == 0 || h == 0 {
+		return 64 << 20
+	}
+	return int64(w) * int64(h) * 4 * 3
+}
+
+// convertOneImage resizes, color-converts and re-encodes one image. The result
+// replaces the source file; when the output format has a different extension
+// the source is removed after the new file is written. When orient is non-nil
+// the page is also turned upright and deskewed; a page that needs no change
+// is not written. Returns the output path and the orientation fix that was
+// applied.
+func convertOneImage(filePath string, opts ConvertOptions, cwebpPath string, orient func(image.Image) orientFix) (string, orientFix, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("open: %w", err)
+		return "", orientFix{}, fmt.Errorf("open: %w", err)
 	}
 
-	orientation := 1
-	if ex, err := exif.Decode(f); err == nil {
-		if tag, err := ex.Get(exif.Orientation); err == nil {
-			if v, err := tag.Int(0); err == nil {
-				orientation = v
-			}
-		}
-	}
-	f.Seek(0, io.SeekStart)
+	orientation := readOrientation(f)
 
-	img, format, err := image.Decode(f)
+	img, srcFormat, err := image.Decode(f)
 	f.Close()
 	if err != nil {
-		return fmt.Errorf("decode: %w", err)
+		return "", orientFix{}, fmt.Errorf("decode: %w", err)
 	}
 
+	if opts.Percent < 100 {
+		bounds := img.Bounds()
+		newW := uint(bounds.Dx() * opts.Percent / 100)
+		newH := uint(bounds.Dy() * opts.Percent / 100)
+		if newW < 1 {
+			newW = 1
+		}
+		if newH < 1 {
+			newH = 1
+		}
+		img = resize.Resize(newW, newH, img, resize.Lanczos3)
+	}
+
+	// Orient after resizing so fewer pixels have to be moved
 	img = applyOrientation(img, orientation)
 
-	bounds := img.Bounds()
-	newW := uint(bounds.Dx() * percent / 100)
-	newH := uint(bounds.Dy() * percent / 100)
-	if newW < 1 {
-		newW = 1
+	var fix orientFix
+	if orient != nil {
+		fix = orient(img)
+		if fix.needed() {
+			img = applyOrientFix(img, fix)
+		}
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = srcFormat
+		if convertFormatExts[format] == "" {
+			format = "jpeg"
+		}
+	}
+
+	// An upright page that is neither resized nor converted is left as it
+	// is; encoding it again would only lose quality
+	if opts.Percent == 100 && orientation == 1 && !fix.needed() &&
+		opts.ColorMode == "" && opts.Format == "" && format == srcFormat {
+		return filePath, fix, nil
+	}
+
+	switch {
+	case opts.ColorMode == "bw" || format == "tiff-g4":
+		img = toBilevel(img)
+	case opts.ColorMode == "gray":
+		img = toGray(img)
 	}
-	if newH < 1 {
-		newH = 1
+
+	outPath := filePath
+	if opts.Format != "" {
+		outPath = replaceImageExt(filePath, convertFormatExts[format])
+		if outPath != filePath {
+			if _, err := os.Stat(outPath); err == nil {
+				return "", fix, fmt.Errorf("target exists: %s", filepath.Base(outPath))
+			}
+		}
 	}
 
-	resized := resize.Resize(newW, newH, img, resize.Lanczos3)
+	tmpPath := outPath + ".tmp"
+	if format == "webp" {
+		if cwebpPath == "" {
+			return "", fix, fmt.Errorf("cwebp not found")
+		}
+		err = encodeWebP(img, tmpPath, opts.Quality, cwebpPath)
+	} else {
+		err = encodeImageFile(img, tmpPath, format, opts.Quality)
+	}
 	img = nil
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fix, fmt.Errorf("encode: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fix, err
+	}
+	if outPath != filePath {
+		if err := os.Remove(filePath); err != nil {
+			return outPath, fix, fmt.Errorf("remove original: %w", err)
+		}
+	}
+	return outPath, fix, nil
+}
 
-	tmpPath := filePath + ".tmp"
-	out, err := os.Create(tmpPath)
+// replaceImageExt swaps the extension of path, keeping it upper-case when the
+// original was (the rename tab writes ".JPG").
+func replaceImageExt(path, newExt string) string {
+	oldExt := filepath.Ext(path)
+	if strings.EqualFold(oldExt, newExt) ||
+		(strings.EqualFold(oldExt, ".jpeg") && newExt == ".jpg") ||
+		(strings.EqualFold(oldExt, ".tiff") && newExt == ".tif") {
+		return path
+	}
+	if oldExt != "" && oldExt == strings.ToUpper(oldExt) {
+		newExt = strings.ToUpper(newExt)
+	}
+	return strings.TrimSuffix(path, oldExt) + newExt
+}
+
+func encodeImageFile(img image.Image, path, format string, quality int) error {
+	out, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("create tmp: %w", err)
 	}
 
-	switch strings.ToLower(format) {
+	switch format {
 	case "png":
-		err = png.Encode(out, resized)
+		err = png.Encode(out, toPNGImage(img))
+	case "tiff":
+		err = tiff.Encode(out, img, &tiff.Options{Compression: tiff.Deflate, Predictor: true})
+	case "tiff-g4":
+		// the caller has made img bilevel
+		err = writeG4TIFF(out, toGray(img))
+	case "bmp":
+		err = bmp.Encode(out, img)
 	default:
-		err = jpeg.Encode(out, resized, &jpeg.Options{Quality: 92})
+		err = jpeg.Encode(out, img, &jpeg.Options{Quality: quality})
 	}
-	resized = nil
 
 	if closeErr := out.Close(); closeErr != nil && err == nil {
 		err = closeErr
 	}
-	if err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("encode: %w", err)
+	return err
+}
+
+// encodeWebP writes img to path via the cwebp command-line encoder, using a
+// lossless PNG as the intermediate.
+func encodeWebP(img image.Image, path string, quality int, cwebpPath string) error {
+	pngPath := path + ".png"
+	if err := encodeImageFile(img, pngPath, "png", quality); err != nil {
+		return err
+	}
+	defer os.Remove(pngPath)
+
+	cmd := exec.Command(cwebpPath, "-quiet", "-q", strconv.Itoa(quality), pngPath, "-o", path)
+	hideCommandWindow(cmd)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("cwebp: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// detectCwebp looks for the cwebp encoder next to the executable, then in PATH.
+func (a *App) detectCwebp() string {
+	name := "cwebp"
+	if runtime.GOOS == "windows" {
+		name = "cwebp.exe"
+	}
+	for _, p := range []string{
+		filepath.Join(a.exeDir(), "cwebp", name),
+		filepath.Join(a.exeDir(), name),
+	} {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	if path, err := exec.LookPath("cwebp"); err == nil {
+		return path
+	}
+	return ""
+}
+
+// --- Color mode helpers ---
+
+// toGray converts img to 8-bit grayscale.
+func toGray(img image.Image) *image.Gray {
+	if g, ok := img.(*image.Gray); ok {
+		return g
 	}
+	b := img.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	if src, ok := img.(*image.YCbCr); ok {
+		for y := 0; y < b.Dy(); y++ {
+			copy(dst.Pix[y*dst.Stride:y*dst.Stride+b.Dx()], src.Y[src.YOffset(b.Min.X, b.Min.Y+y):])
+		}
+		return dst
+	}
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// toBilevel converts img to pure black/white using Otsu's threshold. The
+// result is an *image.Gray holding only 0 and 255.
+func toBilevel(img image.Image) *image.Gray {
+	g := toGray(img)
+	if g == img {
+		// Never modify the caller's image in place
+		c := *g
+		c.Pix = append([]uint8(nil), g.Pix...)
+		g = &c
+	}
+	b := g.Bounds()
+
+	var hist [256]int
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := g.Pix[g.PixOffset(b.Min.X, y):][:b.Dx()]
+		for _, v := range row {
+			hist[v]++
+		}
+	}
+	threshold := otsuThreshold(hist)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := g.Pix[g.PixOffset(b.Min.X, y):][:b.Dx()]
+		for i, v := range row {
+			if v > threshold {
+				row[i] = 255
+			} else {
+				row[i] = 0
+			}
+		}
+	}
+	return g
+}
+
+func otsuThreshold(hist [256]int) uint8 {
+	total := 0
+	sum := 0.0
+	for i, n := range hist {
+		total += n
+		sum += float64(i * n)
+	}
+	if total == 0 {
+		return 127
+	}
+
+	var sumB, best float64
+	wB := 0
+	threshold := uint8(127)
+	for i, n := range hist {
+		wB += n
+		if wB == 0 {
+			continue
+		}
+		wF := total - wB
+		if wF == 0 {
+			break
+		}
+		sumB += float64(i * n)
+		mB := sumB / float64(wB)
+		mF := (sum - sumB) / float64(wF)
+		between := float64(wB) * float64(wF) * (mB - mF) * (mB - mF)
+		if between > best {
+			best = between
+			threshold = uint8(i)
+		}
+	}
+	return threshold
+}
 
-	return os.Rename(tmpPath, filePath)
+// toPNGImage returns a 1-bit paletted image for bilevel input so the PNG
+// encoder writes bit depth 1; other images are returned unchanged.
+func toPNGImage(img image.Image) image.Image {
+	g, ok := img.(*image.Gray)
+	if !ok {
+		return img
+	}
+	for _, v := range g.Pix {
+		if v != 0 && v != 255 {
+			return img
+		}
+	}
+	b := g.Bounds()
+	p := image.NewPaletted(b, color.Palette{color.Gray{0}, color.Gray{255}})
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		src := g.Pix[g.PixOffset(b.Min.X, y):][:b.Dx()]
+		dst := p.Pix[p.PixOffset(b.Min.X, y):][:b.Dx()]
+		for i, v := range src {
+			if v == 255 {
+				dst[i] = 1
+			}
+		}
+	}
+	return p
 }
@@ -0,0 +1,239 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	vision "cloud.google.com/go/vision/v2/apiv1"
+)
+
+// defaultEscalateBelow is the quality score under which a page is escalated
+// when neither the job nor config.json sets one
+const defaultEscalateBelow = 60
+
+// escalation sends the pages the job's provider recognized poorly to a
+// second, usually paid, provider. Thread-safe.
+type escalation struct {
+	provider string          // provider weak pages go to
+	key      string          // its limit key, for the price
+	below    float64         // quality score to escalate below
+	words    map[string]bool // word list for the hit rate; nil = letters-only check
+
+	pages     atomic.Int64 // pages scored
+	weak      atomic.Int64 // pages scored below the threshold
+	escalated atomic.Int64 // weak pages OCR'd again by provider
+}
+
+// escalationFor returns the escalation policy of a run, or nil when it is
+// off, as it is in consensus mode. A word list that cannot be read is
+// returned as an error next to the policy, which then uses the letters-only
+// check.
+func (a *App) escalationFor(settings OCRSettings) (*escalation, error) {
+	if settings.Escalate == "" || settings.Escalate == providerName(settings.Provider) ||
+		consensusEngines(settings) != nil {
+		return nil, nil
+	}
+	target := settings
+	target.Provider = settings.Escalate
+	esc := &escalation{
+		provider: settings.Escalate,
+		key:      limitKey(target),
+		below:    defaultEscalateBelow,
+	}
+	if settings.EscalateBelow > 0 {
+		esc.below = float64(settings.EscalateBelow)
+	} else if a.config.EscalateBelow > 0 {
+		esc.below = float64(a.config.EscalateBelow)
+	}
+	if a.config.WordList == "" {
+		return esc, nil
+	}
+	words, err := loadWordList(a.config.WordList)
+	if err != nil {
+		return esc, err
+	}
+	esc.words = words
+	return esc, nil
+}
+
+// loadWordList reads a word list with one word per line, lowercased
+func loadWordList(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("word list: %w", err)
+	}
+	defer f.Close()
+	words := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if w := strings.TrimSpace(sc.Text()); w != "" {
+			words[strings.ToLower(w)] = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("word list: %w", err)
+	}
+	return words, nil
+}
+
+// pageQuality scores recognized text from 0 to 100 as the lower of the
+// provider's mean word confidence and the word hit rate. The hit rate is
+// the share of words found in the word list or, without one, the share made
+// only of letters or only of digits, which garbled text mostly is not.
+// Pages without any words (blank pages) are not scored.
+func pageQuality(scan scanText, words map[string]bool) (float64, bool) {
+	tokens := strings.FieldsFunc(scan.Left+"\n"+scan.Right, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	if len(tokens) == 0 {
+		return 0, false
+	}
+	hits := 0
+	for _, t := range tokens {
+		if wordHit(t, words) {
+			hits++
+		}
+	}
+	score := float64(hits) / float64(len(tokens)) * 100
+	if scan.Confidence >= 0 {
+		score = min(score, scan.Confidence)
+	}
+	return score, true
+}
+
+// wordHit reports whether token reads as a word. Numbers always do; scripts
+// written without spaces (Chinese, Japanese) cannot be looked up word by
+// word, so they only get the letters-only check.
+func wordHit(token string, words map[string]bool) bool {
+	letters, digits := true, true
+	for _, r := range token {
+		letters = letters && (unicode.IsLetter(r) || unicode.Is(unicode.Mn, r))
+		digits = digits && unicode.IsDigit(r)
+	}
+	if digits {
+		return true
+	}
+	first := []rune(token)[0]
+	if words == nil || unicode.In(first, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return letters
+	}
+	return words[strings.ToLower(token)]
+}
+
+// checkQuality records the confidence and quality score of a page in result
+// and, under an escalation policy, OCRs a page scored below the threshold
+// again with the escalation provider. The first result is kept when that
+// fails or the provider's budget is used up.
+func (a *App) checkQuality(ctx context.Context, client *vision.ImageAnnotatorClient, filePath string, page, pageCount int, settings OCRSettings, result *PageResult, scan scanText) scanText {
+	pace := pacingFrom(ctx)
+	var esc *escalation
+	if pace != nil {
+		esc = pace.escalation
+	}
+	var words map[string]bool
+	if esc != nil {
+		words = esc.words
+	}
+	score, ok := scoreResult(result, scan, words)
+	if esc == nil || !ok || result.Provider == esc.provider {
+		return scan
+	}
+	esc.pages.Add(1)
+	if score >= esc.below {
+		return scan
+	}
+	esc.weak.Add(1)
+
+	s := settings
+	s.Provider = esc.provider
+	attempt := *result
+	attempt.Provider = esc.provider
+	better, err := a.ocrPageWith(ctx, client, filePath, page, pageCount, s, &attempt)
+	if err != nil {
+		var budgetErr *budgetError
+		switch {
+		case ctx.Err() != nil:
+		case errors.As(err, &budgetErr):
+			pace.quota.report(a, budgetErr, result.Provider)
+		default:
+			a.emitOCRLog(result.File, fmt.Sprintf("%s: escalation to %s failed, keeping %s text: %v",
+				pageResultLabel(*result), esc.provider, result.Provider, err), 0, 0, false)
+		}
+		return scan
+	}
+	esc.escalated.Add(1)
+	attempt.Escalated = true
+	scoreResult(&attempt, better, words)
+	*result = attempt
+	return better
+}
+
+// scoreResult stores the confidence and quality score of scan in result
+func scoreResult(result *PageResult, scan scanText, words map[string]bool) (float64, bool) {
+	result.Confidence, result.Quality = 0, 0
+	if scan.Confidence >= 0 {
+		result.Confidence = math.Round(scan.Confidence*10) / 10
+	}
+	score, ok := pageQuality(scan, words)
+	if ok {
+		result.Quality = math.Round(score*10) / 10
+	}
+	return score, ok
+}
+
+// escalationSummary notes the escalated pages of a file for its log line
+func escalationSummary(pages []PageResult) string {
+	n, provider := 0, ""
+	for _, r := range pages {
+		if r.Escalated {
+			n++
+			provider = r.Provider
+		}
+	}
+	switch {
+	case n == 0:
+		return ""
+	case len(pages) == 1:
+		return ", escalated to " + provider
+	}
+	return fmt.Sprintf(", %d pages escalated to %s", n, provider)
+}
+
+// emitEscalationReport logs how many pages went to the escalation provider
+// and what the pages scored above the threshold saved at its list price;
+// the free tier is left out
+func (a *App) emitEscalationReport(esc *escalation) {
+	if esc == nil || esc.pages.Load() == 0 {
+		return
+	}
+	price := a.priceFor(esc.key).PerThousand
+	r := EscalationReport{
+		Provider:  esc.key,
+		Below:     int(esc.below),
+		Pages:     int(esc.pages.Load()),
+		Escalated: int(esc.escalated.Load()),
+	}
+	weak := int(esc.weak.Load())
+	kept := r.Pages - weak
+	r.Cost = float64(r.Escalated) * price / 1000
+	r.Saved = float64(kept) * price / 1000
+
+	msg := fmt.Sprintf("Escalation report: %d of %d pages scored below %d, %d went to %s",
+		weak, r.Pages, r.Below, r.Escalated, esc.provider)
+	if price > 0 {
+		msg += fmt.Sprintf(" ($%.2f); the %d others saved $%.2f", r.Cost, kept, r.Saved)
+	} else {
+		msg += fmt.Sprintf("; the %d others saved as many calls", kept)
+	}
+	a.emitOCRLog("", msg, 0, 0, false)
+	if a.onEscalation != nil {
+		a.onEscalation(r)
+	}
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// defaultPricing holds the list prices in USD per 1000 calls and the free
+// calls per month. Google Vision text detection costs $1.50 per 1000 units
+// after the first 1000 a month (the $0.60 tier above 5M units is left out);
+// OCR.space PRO is a flat subscription and the free plan and Tesseract cost
+// nothing per call.
+func defaultPricing() map[string]ProviderPrice {
+	return map[string]ProviderPrice{
+		"google": {PerThousand: 1.50, FreeMonthly: 1000},
+	}
+}
+
+// priceFor returns the price of a limit key; an entry in config.json
+// replaces the built-in one
+func (a *App) priceFor(key string) ProviderPrice {
+	if p, ok := a.config.Pricing[key]; ok {
+		return p
+	}
+	return defaultPricing()[key]
+}
+
+// EstimateOCR works out what running settings would cost: the pages still
+// to OCR, the free calls left this month and the price of the rest, plus the
+// consensus engines that OCR every page too. Escalation and fallback engines
+// are priced as if every page went to them, which MaxCost includes. Pages
+// found in the OCR cache are not subtracted, so the figures are upper bounds.
+func (a *App) EstimateOCR(settings OCRSettings) (OCREstimate, error) {
+	if len(settings.SelectedFiles) == 0 {
+		return OCREstimate{}, errors.New("no files selected")
+	}
+	key := limitKey(settings)
+	est := OCREstimate{Provider: key, FreeLeft: -1, BudgetLeft: -1}
+
+	plan := a.planOCRRun(settings, loadPageResults(settings.OutputDir), loadFailureLog(settings.OutputDir))
+	est.Files = len(plan.remaining)
+	if settings.ImageOnly {
+		est.Summary = fmt.Sprintf("%d files, images to PDF: no provider calls", est.Files)
+		return est, nil
+	}
+	for _, f := range plan.remaining {
+		est.Pages += estimatePages(f)
+	}
+
+	est.PerThousand = a.priceFor(key).PerThousand
+	free := make(map[string]int)
+	est.FreeLeft, est.Billable, est.Cost = a.estimateCalls(key, est.Pages, free)
+	est.MaxCost = est.Cost
+
+	addEngine := func(role, provider string) {
+		s := settings
+		s.Provider = provider
+		e := EngineEstimate{Provider: limitKey(s), Role: role, Calls: est.Pages}
+		_, e.Billable, e.Cost = a.estimateCalls(e.Provider, e.Calls, free)
+		est.Engines = append(est.Engines, e)
+		if role == "consensus" {
+			est.Cost += e.Cost
+		}
+		est.MaxCost += e.Cost
+	}
+	if engines := consensusEngines(settings); engines != nil {
+		for _, p := range engines[1:] {
+			addEngine("consensus", p)
+		}
+	} else {
+		if esc, _ := a.escalationFor(settings); esc != nil {
+			addEngine("escalate", esc.provider)
+		}
+		for _, p := range providerChain(settings)[1:] {
+			addEngine("fallback", p)
+		}
+	}
+
+	a.statsMu.Lock()
+	day, month := a.quotaUsed(key, time.Now())
+	a.statsMu.Unlock()
+
+	budget := a.quotaBudgetFor(key)
+	if budget.Daily > 0 {
+		est.BudgetLeft = max(budget.Daily-day, 0)
+	}
+	if budget.Monthly > 0 && (est.BudgetLeft < 0 || budget.Monthly-month < est.BudgetLeft) {
+		est.BudgetLeft = max(budget.Monthly-month, 0)
+	}
+
+	est.Summary = estimateSummary(est)
+	return est, nil
+}
+
+// estimateCalls prices calls to a limit key. free holds the free calls left
+// this month by key, so that engines sharing a free tier in one estimate do
+// not count it twice; freeLeft is what was left before these calls, -1
+// without a free tier.
+func (a *App) estimateCalls(key string, calls int, free map[string]int) (freeLeft, billable int, cost float64) {
+	price := a.priceFor(key)
+	freeLeft, billable = -1, calls
+	if price.FreeMonthly > 0 {
+		left, ok := free[key]
+		if !ok {
+			a.statsMu.Lock()
+			_, month := a.quotaUsed(key, time.Now())
+			a.statsMu.Unlock()
+			left = max(price.FreeMonthly-month, 0)
+		}
+		freeLeft = left
+		billable = max(calls-left, 0)
+		free[key] = max(left-calls, 0)
+	}
+	return freeLeft, billable, float64(billable) * price.PerThousand / 1000
+}
+
+// estimatePages counts the provider calls a file takes: one per TIFF page
+// or per PDF page (pages without an image are skipped when the run gets to
+// them), one for any other image
+func estimatePages(path string) int {
+	if isPDFInput(path) {
+		if n, err := pdfcpuapi.PageCountFile(path); err == nil {
+			return n
+		}
+		return 1
+	}
+	if n, err := ocrPageCount(path); err == nil {
+		return n
+	}
+	return 1
+}
+
+// estimateSummary reads like "412 pages × $1.50/1000 = $0.62; 388 calls left
+// in free tier this month, $0.04 after them"
+func estimateSummary(est OCREstimate) string {
+	s := fmt.Sprintf("%d pages, no per-call cost", est.Pages)
+	if est.PerThousand > 0 {
+		s = fmt.Sprintf("%d pages × $%.2f/1000 = $%.2f", est.Pages, est.PerThousand,
+			float64(est.Pages)*est.PerThousand/1000)
+	}
+	if est.FreeLeft >= 0 {
+		s += fmt.Sprintf("; %d calls left in free tier this month", est.FreeLeft)
+		if est.PerThousand > 0 && est.Billable < est.Pages {
+			s += fmt.Sprintf(", $%.2f after them", float64(est.Billable)*est.PerThousand/1000)
+		}
+	}
+	if est.BudgetLeft >= 0 && est.BudgetLeft < est.Pages {
+		s += fmt.Sprintf("; the %s quota budget stops the run after %d calls", est.Provider, est.BudgetLeft)
+	}
+	for _, e := range est.Engines {
+		switch e.Role {
+		case "consensus":
+			s += fmt.Sprintf("; consensus with %s: %d calls, $%.2f", e.Provider, e.Calls, e.Cost)
+		case "escalate":
+			s += fmt.Sprintf("; escalation to %s: up to %d calls, $%.2f", e.Provider, e.Calls, e.Cost)
+		default:
+			s += fmt.Sprintf("; fallback to %s: up to %d calls, $%.2f", e.Provider, e.Calls, e.Cost)
+		}
+	}
+	if len(est.Engines) > 0 {
+		s += fmt.Sprintf("; $%.2f in all", est.Cost)
+		if est.MaxCost > est.Cost {
+			s += fmt.Sprintf(", at most $%.2f", est.MaxCost)
+		}
+	}
+	return s
+}
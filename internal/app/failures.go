@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failuresFile is written to the OCR output folder and records every failed
+// attempt to process a file, so failed files can be retried on their own.
+const failuresFile = "ocr-failures.json"
+
+// Error classes recorded in FailureRecord.Class
+const (
+	errClassAuth     = "auth"     // credentials rejected
+	errClassQuota    = "quota"    // rate limit or quota exceeded
+	errClassTimeout  = "timeout"  // provider did not answer in time
+	errClassNetwork  = "network"  // provider unreachable
+	errClassServer   = "server"   // provider-side failure (HTTP 5xx, gRPC unavailable)
+	errClassInput    = "input"    // provider rejected the image
+	errClassProvider = "provider" // any other provider error
+	errClassLocal    = "local"    // reading, decoding or writing files
+	errClassBudget   = "budget"   // own daily or monthly usage budget used up
+)
+
+// httpStatusError is a non-200 answer from an HTTP provider
+type httpStatusError struct {
+	Code int
+	Body string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.Code, e.Body)
+}
+
+// providerError marks an error returned by the provider call itself, as
+// opposed to the local work around it
+type providerError struct {
+	provider string // provider that was called
+	err      error
+}
+
+func (e *providerError) Error() string { return e.err.Error() }
+func (e *providerError) Unwrap() error { return e.err }
+
+// failedProvider names the provider that produced err, which differs from
+// the job's when a fallback or quota fallback provider made the last attempt
+func failedProvider(err error, jobProvider string) string {
+	var provErr *providerError
+	if errors.As(err, &provErr) && provErr.provider != "" {
+		return provErr.provider
+	}
+	return jobProvider
+}
+
+// classifyOCRError sorts a file's error into one of the errClass values
+func classifyOCRError(err error) string {
+	var httpErr *httpStatusError
+	var budgetErr *budgetError
+	var urlErr *url.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &budgetErr):
+		return errClassBudget
+	case errors.Is(err, context.DeadlineExceeded):
+		return errClassTimeout
+	case errors.As(err, &httpErr):
+		switch {
+		case httpErr.Code == 401 || httpErr.Code == 403:
+			return errClassAuth
+		case httpErr.Code == 429:
+			return errClassQuota
+		case httpErr.Code >= 500:
+			return errClassServer
+		}
+		return errClassInput
+	case errors.As(err, &urlErr) && urlErr.Timeout():
+		return errClassTimeout
+	case errors.As(err, &urlErr), errors.As(err, &opErr), errors.As(err, &dnsErr):
+		return errClassNetwork
+	}
+
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return errClassAuth
+	case codes.ResourceExhausted:
+		return errClassQuota
+	case codes.DeadlineExceeded:
+		return errClassTimeout
+	case codes.Unavailable, codes.Internal:
+		return errClassServer
+	case codes.InvalidArgument:
+		return errClassInput
+	}
+
+	var provErr *providerError
+	if errors.As(err, &provErr) {
+		return errClassProvider
+	}
+	return errClassLocal
+}
+
+// failureLog is the in-memory copy of failuresFile. Thread-safe; workers
+// record failures concurrently.
+type failureLog struct {
+	mu      sync.Mutex
+	path    string
+	records []FailureRecord
+}
+
+func loadFailureLog(outputDir string) *failureLog {
+	l := &failureLog{path: filepath.Join(outputDir, failuresFile)}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return l
+	}
+	if err := json.Unmarshal(data, &l.records); err != nil {
+		l.records = nil
+	}
+	return l
+}
+
+func (l *failureLog) add(file, provider string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, FailureRecord{
+		File:     file,
+		Provider: providerName(provider),
+		Class:    classifyOCRError(err),
+		Error:    err.Error(),
+		Time:     time.Now().Format(time.RFC3339),
+	})
+}
+
+// resolve marks the failures of file as resolved once it succeeded; they
+// stay in the history
+func (l *failureLog) resolve(file string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.records {
+		if l.records[i].File == file {
+			l.records[i].Resolved = true
+		}
+	}
+}
+
+func (l *failureLog) save() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.records) == 0 {
+		return
+	}
+	data, err := json.MarshalIndent(l.records, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(l.path, data, 0644)
+}
+
+// unresolved returns the latest failure of every file that has not
+// succeeded since, sorted by file name
+func (l *failureLog) unresolved() []FailureRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	latest := make(map[string]FailureRecord)
+	for _, r := range l.records {
+		if !r.Resolved {
+			latest[r.File] = r
+		}
+	}
+	list := make([]FailureRecord, 0, len(latest))
+	for _, r := range latest {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].File < list[j].File })
+	return list
+}
+
+// emitFailureReport logs the files that are still failed, with a count per
+// error class. The lines are not errors: each failure was reported when it
+// happened.
+func (a *App) emitFailureReport(failed []FailureRecord) {
+	if len(failed) == 0 {
+		return
+	}
+	classes := make(map[string]int)
+	for _, r := range failed {
+		classes[r.Class]++
+	}
+	var parts []string
+	for c, n := range classes {
+		parts = append(parts, fmt.Sprintf("%s %d", c, n))
+	}
+	sort.Strings(parts)
+	summary := strings.Join(parts, ", ")
+	a.emitOCRLog("", fmt.Sprintf("Failure report: %d files failed (%s); use Retry Failed to run them again", len(failed), summary), 0, 0, false)
+	for _, r := range failed {
+		a.emitOCRLog(r.File, fmt.Sprintf("Failed [%s] with %s at %s: %s", r.Class, r.Provider, r.Time, r.Error), 0, 0, false)
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	vision "cloud.google.com/go/vision/v2/apiv1"
+)
+
+// ocrProviders are the provider names a fallback list may hold
+var ocrProviders = []string{"google", "ocrspace", "tesseract"}
+
+// providerChain is the job's provider followed by its fallbacks, each once
+func providerChain(settings OCRSettings) []string {
+	chain := []string{providerName(settings.Provider)}
+	for _, p := range settings.Fallbacks {
+		if slices.Contains(ocrProviders, p) && !slices.Contains(chain, p) {
+			chain = append(chain, p)
+		}
+	}
+	return chain
+}
+
+// usesProvider reports whether a run with settings may call provider, as
+// part of the fallback chain, as the quota fallback, for escalation or to
+// vote
+func usesProvider(settings OCRSettings, provider string) bool {
+	return slices.Contains(providerChain(settings), provider) || settings.QuotaFallback == provider ||
+		settings.Escalate == provider || slices.Contains(consensusEngines(settings), provider)
+}
+
+// fallbackSummary notes the pages of a file that another provider than the
+// job's produced, for the file's log line
+func fallbackSummary(pages []PageResult, provider string) string {
+	var by []string
+	n := 0
+	for _, r := range pages {
+		if r.Provider == providerName(provider) || r.Provider == imageOnlyProvider || r.Escalated ||
+			r.Provider == consensusProvider {
+			continue
+		}
+		n++
+		if !slices.Contains(by, r.Provider) {
+			by = append(by, r.Provider)
+		}
+	}
+	switch {
+	case n == 0:
+		return ""
+	case len(pages) == 1:
+		return ", by " + by[0]
+	}
+	return fmt.Sprintf(", %d pages by %s", n, strings.Join(by, ", "))
+}
+
+// ocrOnePage OCRs one page with the job's provider. A page the provider
+// cannot do, after retries, goes to the next provider of the fallback chain;
+// one it does poorly may be escalated (see checkQuality). In consensus mode
+// every engine OCRs the page instead (see ocrConsensus). result records the
+// provider that produced it.
+func (a *App) ocrOnePage(ctx context.Context, client *vision.ImageAnnotatorClient, filePath string, page, pageCount int, settings OCRSettings, result *PageResult) (scanText, error) {
+	if engines := consensusEngines(settings); engines != nil {
+		scan, err := a.ocrConsensus(ctx, client, filePath, page, pageCount, settings, engines, result)
+		if err != nil {
+			return scanText{}, err
+		}
+		return a.checkQuality(ctx, client, filePath, page, pageCount, settings, result, scan), nil
+	}
+
+	chain := providerChain(settings)
+	pace := pacingFrom(ctx)
+	for i, provider := range chain {
+		s := settings
+		s.Provider = provider
+		last := i == len(chain)-1
+
+		// A provider whose usage budget is used up hands the page to the
+		// quota fallback or the next provider; without one the page is
+		// left for a later run
+		if pace != nil {
+			var err error
+			if s, err = a.withinBudget(s, &pace.quota); err != nil {
+				var budgetErr *budgetError
+				if last || !errors.As(err, &budgetErr) {
+					return scanText{}, err
+				}
+				pace.quota.report(a, budgetErr, chain[i+1])
+				continue
+			}
+		}
+
+		attempt := *result
+		attempt.Provider = s.Provider
+		scan, err := a.ocrPageWith(ctx, client, filePath, page, pageCount, s, &attempt)
+		if err == nil {
+			*result = attempt
+			return a.checkQuality(ctx, client, filePath, page, pageCount, settings, result, scan), nil
+		}
+		var provErr *providerError
+		if last || ctx.Err() != nil || !errors.As(err, &provErr) {
+			if len(chain) > 1 {
+				err = fmt.Errorf("%s: %w", s.Provider, err)
+			}
+			return scanText{}, err
+		}
+		a.emitOCRLog(result.File, fmt.Sprintf("%s: %s failed [%s], trying %s: %v",
+			pageResultLabel(*result), s.Provider, classifyOCRError(err), chain[i+1], err), 0, 0, false)
+	}
+	return scanText{}, errors.New("no OCR provider")
+}
@@ -0,0 +1,322 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vision "cloud.google.com/go/vision/v2/apiv1"
+	"github.com/go-pdf/fpdf"
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// imageOnlyProvider is recorded in ocr-results.json for pages written by
+// images-to-PDF mode, so a later OCR run adds a text layer to their PDF
+// instead of skipping or rebuilding it.
+const imageOnlyProvider = "none"
+
+// imagePDFPageWidth is the width in mm of one book page in an image PDF; the
+// height follows the aspect ratio of the scan.
+const imagePDFPageWidth = 210.0
+
+// generateImagePDF writes the scans in filePath to outputPath as image pages
+// without any text: one page per scan in single mode, a left and a right page
+// (the two halves of the spread) in dual mode. Page labels come from the
+// file name.
+func generateImagePDF(outputPath, filePath, scanMode string) ([]PageResult, error) {
+	baseName := filepath.Base(filePath)
+	pageCount, err := ocrPageCount(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+
+	leftLabel, rightLabel := pageLabelsFromFilename(baseName)
+	singleLabel := pageLabelFromFilenameSingle(baseName)
+
+	var labels []string
+	results := make([]PageResult, 0, pageCount)
+	for page := 0; page < pageCount; page++ {
+		data, imgType, w, h, err := pdfImageData(filePath, page, pageCount)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("scan%d", page)
+		opts := fpdf.ImageOptions{ImageType: imgType}
+		pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(data))
+
+		if scanMode == "single" {
+			pageH := imagePDFPageWidth * h / w
+			pdf.AddPageFormat("P", fpdf.SizeType{Wd: imagePDFPageWidth, Ht: pageH})
+			pdf.ImageOptions(name, 0, 0, imagePDFPageWidth, pageH, false, opts, 0, "")
+			labels = append(labels, singleLabel)
+		} else {
+			// The whole spread is drawn on both pages, shifted so that the
+			// page box crops away the other half; the image is stored once
+			pageH := 2 * imagePDFPageWidth * h / w
+			for i, label := range []string{leftLabel, rightLabel} {
+				pdf.AddPageFormat("P", fpdf.SizeType{Wd: imagePDFPageWidth, Ht: pageH})
+				pdf.ImageOptions(name, -float64(i)*imagePDFPageWidth, 0, 2*imagePDFPageWidth, pageH, false, opts, 0, "")
+				labels = append(labels, label)
+			}
+		}
+
+		result := PageResult{File: baseName, Provider: imageOnlyProvider}
+		if pageCount > 1 {
+			result.Page = page + 1
+		}
+		results = append(results, result)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := pdfcpuapi.ReadContext(bytes.NewReader(buf.Bytes()), model.NewDefaultConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("page labels: %w", err)
+	}
+	if err := setPDFPageLabels(pdfCtx, labels); err != nil {
+		return nil, fmt.Errorf("page labels: %w", err)
+	}
+	if err := pdfcpuapi.WriteContextFile(pdfCtx, outputPath); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+	return results, nil
+}
+
+// pdfImageData returns page (0-based) of filePath in a form fpdf can embed,
+// with its pixel size. Single-page JPEGs and non-interlaced PNGs without an
+// EXIF rotation are embedded as they are; everything else is decoded, turned
+// upright and re-encoded.
+func pdfImageData(filePath string, page, pageCount int) ([]byte, string, float64, float64, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if pageCount <= 1 && (ext == ".jpg" || ext == ".jpeg" || ext == ".png") {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, "", 0, 0, fmt.Errorf("read: %w", err)
+		}
+		orientation := readOrientation(bytes.NewReader(data))
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", 0, 0, fmt.Errorf("decode: %w", err)
+		}
+		// Byte 28 is the PNG interlace method, which fpdf cannot read
+		interlaced := format == "png" && len(data) > 28 && data[28] != 0
+		if (orientation <= 1 || orientation > 8) && !interlaced {
+			imgType := "JPG"
+			if format == "png" {
+				imgType = "PNG"
+			}
+			return data, imgType, float64(cfg.Width), float64(cfg.Height), nil
+		}
+	}
+
+	img, err := decodeOCRPage(filePath, page)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+	data, ext, err := encodeOCRImage(img)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+	b := img.Bounds()
+	return data, strings.ToUpper(strings.TrimPrefix(ext, ".")), float64(b.Dx()), float64(b.Dy()), nil
+}
+
+// pdfPageLabel turns a label from pageLabelsFromFilename ("Page 12") into a
+// PDF page label ("12").
+func pdfPageLabel(label string) string {
+	return strings.TrimPrefix(label, "Page ")
+}
+
+// setPDFPageLabels sets one page label per page of pdfCtx. Pages without a
+// label keep their physical page number. Nothing is written when no page
+// has a label.
+func setPDFPageLabels(pdfCtx *model.Context, labels []string) error {
+	var nums types.Array
+	hasLabel := false
+	for i, label := range labels {
+		label = pdfPageLabel(label)
+		if label == "" {
+			nums = append(nums, types.Integer(i), types.Dict{"S": types.Name("D"), "St": types.Integer(i + 1)})
+			continue
+		}
+		hasLabel = true
+		nums = append(nums, types.Integer(i), types.Dict{"P": types.StringLiteral(label)})
+	}
+	if !hasLabel {
+		return nil
+	}
+	return setPDFPageLabelNums(pdfCtx, nums)
+}
+
+func setPDFPageLabelNums(pdfCtx *model.Context, nums types.Array) error {
+	catalog, err := pdfCtx.Catalog()
+	if err != nil {
+		return err
+	}
+	catalog["PageLabels"] = types.Dict{"Nums": nums}
+	return nil
+}
+
+// pdfPageLabelNums returns the flat /Nums array of a PDF's page label tree,
+// with every label dictionary copied out of the file so it can be moved to
+// another document. Returns nil when the PDF has no (flat) page labels.
+func pdfPageLabelNums(pdfCtx *model.Context) (types.Array, error) {
+	catalog, err := pdfCtx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	obj, found := catalog.Find("PageLabels")
+	if !found {
+		return nil, nil
+	}
+	tree, err := pdfCtx.DereferenceDict(obj)
+	if err != nil || tree == nil {
+		return nil, err
+	}
+	arr, err := pdfCtx.DereferenceArray(tree["Nums"])
+	if err != nil {
+		return nil, err
+	}
+
+	var nums types.Array
+	for i := 0; i+1 < len(arr); i += 2 {
+		start, ok := arr[i].(types.Integer)
+		if !ok {
+			continue
+		}
+		d, err := pdfCtx.DereferenceDict(arr[i+1])
+		if err != nil || d == nil {
+			continue
+		}
+		label := types.Dict{}
+		for k, v := range d {
+			if v, err = pdfCtx.Dereference(v); err == nil && v != nil {
+				label[k] = v
+			}
+		}
+		nums = append(nums, start, label)
+	}
+	return nums, nil
+}
+
+// mergePDFPageLabels gives mergedPath the page labels of its source files,
+// shifted by each file's position in the merge. Sources without labels keep
+// their physical page numbers. pdfcpu's merge keeps only the first file's
+// catalog, so without this the labels of later files would be lost.
+func mergePDFPageLabels(pdfFiles []string, mergedPath string) error {
+	var nums types.Array
+	hasLabels := false
+	offset := 0
+	for _, f := range pdfFiles {
+		pdfCtx, err := pdfcpuapi.ReadContextFile(f)
+		if err != nil {
+			return err
+		}
+		fileNums, err := pdfPageLabelNums(pdfCtx)
+		if err != nil {
+			return err
+		}
+		if len(fileNums) == 0 {
+			nums = append(nums, types.Integer(offset), types.Dict{"S": types.Name("D"), "St": types.Integer(offset + 1)})
+		} else {
+			hasLabels = true
+			for i := 0; i+1 < len(fileNums); i += 2 {
+				nums = append(nums, types.Integer(offset+int(fileNums[i].(types.Integer))), fileNums[i+1])
+			}
+		}
+		offset += pdfCtx.PageCount
+	}
+	if !hasLabels {
+		return nil
+	}
+
+	pdfCtx, err := pdfcpuapi.ReadContextFile(mergedPath)
+	if err != nil {
+		return err
+	}
+	if err := setPDFPageLabelNums(pdfCtx, nums); err != nil {
+		return err
+	}
+	return pdfcpuapi.WriteContextFile(pdfCtx, mergedPath)
+}
+
+// addTextLayer OCRs filePath and stamps the text onto outputPath, the image
+// PDF written for it earlier by images-to-PDF mode, leaving the images and
+// page labels in place.
+func (a *App) addTextLayer(ctx context.Context, client *vision.ImageAnnotatorClient, filePath, outputPath string, settings OCRSettings, fontPath string) ([]PageResult, error) {
+	baseName := filepath.Base(filePath)
+	pageCount, err := ocrPageCount(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The image PDF holds the scan as it is, so the text positions must not
+	// be moved by an orientation fix
+	pageSettings := settings
+	pageSettings.AutoOrient = false
+
+	var pages []scanText
+	results := make([]PageResult, 0, pageCount)
+	for page := 0; page < pageCount; page++ {
+		result := PageResult{File: baseName, Provider: settings.Provider, TextLayer: true}
+		if pageCount > 1 {
+			result.Page = page + 1
+		}
+		scan, err := a.ocrOnePage(ctx, client, filePath, page, pageCount, pageSettings, &result)
+		if err != nil {
+			if pageCount > 1 {
+				return nil, fmt.Errorf("page %d: %w", page+1, err)
+			}
+			return nil, err
+		}
+		pages = append(pages, splitScanPages(scan, settings.ScanMode)...)
+		results = append(results, result)
+	}
+
+	dims, err := pdfcpuapi.PageDimsFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("read pdf: %w", err)
+	}
+	if len(dims) != len(pages) {
+		return nil, fmt.Errorf("%s has %d pages, expected %d (scan mode changed?)", filepath.Base(outputPath), len(dims), len(pages))
+	}
+	if err := stampTextLayer(outputPath, "", dims, pages, fontPath); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// splitScanPages returns the text of each PDF page of a scan: the scan
+// itself in single mode, its left and right half in dual mode. Block
+// coordinates are rescaled to the half they fall in.
+func splitScanPages(scan scanText, scanMode string) []scanText {
+	if scanMode == "single" {
+		return []scanText{scan}
+	}
+	left := scanText{Left: scan.Left}
+	right := scanText{Left: scan.Right}
+	for _, b := range scan.Blocks {
+		if (b.X0+b.X1)/2 < 0.5 {
+			b.X0, b.X1 = b.X0*2, min(b.X1*2, 1)
+			left.Blocks = append(left.Blocks, b)
+		} else {
+			b.X0, b.X1 = max(b.X0*2-1, 0), b.X1*2-1
+			right.Blocks = append(right.Blocks, b)
+		}
+	}
+	return []scanText{left, right}
+}
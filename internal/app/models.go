@@ -24,7 +24,7 @@ type OCRSettings struct {
 	OutputDir      string   `json:"outputDir"`
 	CredFile       string   `json:"credFile"`
 	Languages      []string `json:"languages"`
-	Concurrency    int      `json:"concurrency"`
+	Concurrency    int      `json:"concurrency"` // workers to start with (0 = auto: CPU count for Tesseract, else 5, within the provider's limit)
 	MergePDF       bool     `json:"mergePdf"`
 	MergeFilename  string   `json:"mergeFilename"`
 	ScanMode       string   `json:"scanMode"`       // "dual" or "single"
@@ -34,6 +34,45 @@ type OCRSettings struct {
 	OcrSpacePlan   string   `json:"ocrSpacePlan"`   // "free" or "pro"
 	TesseractPath  string   `json:"tesseractPath"`  // path to tesseract.exe
 	SelectedFiles  []string `json:"selectedFiles"`  // user-selected file paths from frontend
+	AutoOrient     bool     `json:"autoOrient"`     // detect page rotation/skew before OCR
+	ImageOnly      bool     `json:"imageOnly"`      // build image PDFs without calling a provider
+	NoCache        bool     `json:"noCache"`        // always call the provider, bypassing the OCR cache
+	RetryFailed    bool     `json:"retryFailed"`    // only run the files recorded as failed in ocr-failures.json
+	Retries        int      `json:"retries"`        // retries of a transient provider error (0 = config, -1 = none)
+	RetryBaseMs    int      `json:"retryBaseMs"`    // first backoff in ms (0 = config)
+	RetryMaxMs     int      `json:"retryMaxMs"`     // backoff limit in ms (0 = config)
+	RatePerSecond  int      `json:"ratePerSecond"`  // provider requests per second (0 = config, -1 = no limit)
+	RatePerMinute  int      `json:"ratePerMinute"`  // provider requests per minute (0 = config, -1 = no limit)
+	RatePerHour    int      `json:"ratePerHour"`    // provider requests per hour (0 = config, -1 = no limit)
+	MaxConcurrency int      `json:"maxConcurrency"` // ceiling the adaptive worker count may ramp up to (0 = config)
+	QuotaFallback  string   `json:"quotaFallback"`  // provider to switch to when the usage budget is used up ("" = stop)
+	Fallbacks      []string `json:"fallbacks"`      // providers tried in order on a page the provider fails
+	Escalate       string   `json:"escalate"`       // provider to OCR again the pages scored below EscalateBelow ("" = off)
+	EscalateBelow  int      `json:"escalateBelow"`  // page quality score (0-100) under which a page is escalated (0 = config)
+	Consensus      []string `json:"consensus"`      // providers that also OCR every page, their words voted with the provider's
+	VisionBatch    int      `json:"visionBatch"`    // images per Google Vision call, 1-16 (0 = config)
+	VisionEndpoint string   `json:"visionEndpoint"` // Google Vision host:port ("" = config, else the public API)
+	VisionInsecure bool     `json:"visionInsecure"` // dial VisionEndpoint without TLS, for a local emulator
+	VisionAuth     string   `json:"visionAuth"`     // "file", "adc", "apikey" or "none" ("" = config, else "file")
+	VisionApiKey   string   `json:"visionApiKey"`   // Google Vision API key for "apikey" auth ("" = config)
+
+	// OCR.space connection; empty values come from config.json
+	OcrSpaceURL        string `json:"ocrSpaceUrl"`        // OCR.space endpoint ("" = config, else the public API)
+	OcrSpaceTimeoutSec int    `json:"ocrSpaceTimeoutSec"` // OCR.space request timeout in seconds (0 = config)
+	OcrSpaceProxy      string `json:"ocrSpaceProxy"`      // proxy URL for OCR.space ("" = config, else HTTPS_PROXY)
+}
+
+// ConvertOptions holds Convert tab configuration
+type ConvertOptions struct {
+	Percent   int    `json:"percent"`   // 1-100, 100 = keep original size
+	Format    string `json:"format"`    // "" (keep), "jpeg", "png", "tiff", "tiff-g4", "webp"
+	ColorMode string `json:"colorMode"` // "" (keep), "gray", "bw"
+	Quality   int    `json:"quality"`   // JPEG/WebP quality 1-100 (0 = default 92)
+
+	Concurrency    int `json:"concurrency"`    // worker count (0 = min(CPU count, 4))
+	MemoryBudgetMB int `json:"memoryBudgetMb"` // decode memory budget in MB (0 = 1024)
+
+	AutoOrient bool `json:"autoOrient"` // detect and fix page rotation/skew
 }
 
 // AppConfig persisted to config.json next to executable
@@ -53,10 +92,117 @@ type AppConfig struct {
 	OcrSpacePlan   string   `json:"ocrSpacePlan"`   // "free" or "pro"
 	TesseractPath  string   `json:"tesseractPath"`  // path to tesseract.exe
 	ImageDir       string   `json:"imageDir"`       // last used image folder
+	AutoOrient     bool     `json:"autoOrient"`     // detect page rotation/skew before OCR
+	ImageOnly      bool     `json:"imageOnly"`      // images-to-PDF mode, no OCR
+	CacheMaxMB     int      `json:"cacheMaxMb"`     // OCR cache size limit in MB (0 = 500)
+	Retries        int      `json:"retries"`        // retries of a transient provider error (0 = 3, -1 = none)
+	RetryBaseMs    int      `json:"retryBaseMs"`    // first backoff in ms, doubled per retry (0 = 1000)
+	RetryMaxMs     int      `json:"retryMaxMs"`     // backoff limit in ms (0 = 30000)
+
+	// Request rates and worker ceiling by provider: "google", "ocrspace-free",
+	// "ocrspace-pro", "tesseract". Unset values keep the built-in defaults.
+	ProviderLimits map[string]ProviderLimit `json:"providerLimits,omitempty"`
+	// Daily and monthly call budgets by provider, keyed like ProviderLimits
+	QuotaBudgets  map[string]QuotaBudget `json:"quotaBudgets,omitempty"`
+	QuotaFallback string                 `json:"quotaFallback,omitempty"` // provider to switch to when a budget is used up ("" = stop)
+	Fallbacks     []string               `json:"fallbacks,omitempty"`     // providers tried in order on a page the provider fails
+	// Prices by provider, keyed like ProviderLimits; an entry replaces the
+	// built-in price
+	Pricing map[string]ProviderPrice `json:"pricing,omitempty"`
+
+	Escalate      string   `json:"escalate,omitempty"`      // provider for pages scored below EscalateBelow ("" = off)
+	EscalateBelow int      `json:"escalateBelow,omitempty"` // page quality score to escalate below (0 = 60)
+	WordList      string   `json:"wordList,omitempty"`      // word list file, one word per line, for the dictionary hit rate
+	Consensus     []string `json:"consensus,omitempty"`     // providers that also OCR every page for consensus voting
+	VisionBatch   int      `json:"visionBatch,omitempty"`   // images per Google Vision call, 1-16 (0 = 16)
+
+	// Google Vision connection: an endpoint and plaintext mode for a local
+	// emulator, and how to authenticate ("file", "adc", "apikey" or "none")
+	VisionEndpoint string `json:"visionEndpoint,omitempty"`
+	VisionInsecure bool   `json:"visionInsecure,omitempty"`
+	VisionAuth     string `json:"visionAuth,omitempty"`
+	VisionApiKey   string `json:"visionApiKey,omitempty"`
+
+	// OCR.space connection: the endpoint (e.g. a PRO endpoint or a local
+	// stand-in), request timeout in seconds (0 = 60) and proxy URL
+	OcrSpaceURL        string `json:"ocrSpaceUrl,omitempty"`
+	OcrSpaceTimeoutSec int    `json:"ocrSpaceTimeoutSec,omitempty"`
+	OcrSpaceProxy      string `json:"ocrSpaceProxy,omitempty"`
+}
+
+// ProviderLimit caps the requests sent to one provider. 0 keeps the
+// default, -1 turns the limit off.
+type ProviderLimit struct {
+	PerSecond      int `json:"perSecond,omitempty"`
+	PerMinute      int `json:"perMinute,omitempty"`
+	PerHour        int `json:"perHour,omitempty"`
+	MaxConcurrency int `json:"maxConcurrency,omitempty"` // ceiling for the adaptive worker count
+}
+
+// QuotaBudget caps the calls to one provider per calendar day and month,
+// counted from stats.json. 0 and -1 leave it unlimited.
+type QuotaBudget struct {
+	Daily   int `json:"daily,omitempty"`
+	Monthly int `json:"monthly,omitempty"`
+}
+
+// ProviderPrice is what calls to one provider cost
+type ProviderPrice struct {
+	PerThousand float64 `json:"perThousand"`           // USD per 1000 calls
+	FreeMonthly int     `json:"freeMonthly,omitempty"` // calls per month that cost nothing
 }
 
-// Session persisted to session.json for resume capability
+// OCREstimate is the pre-flight cost estimate of an OCR run
+type OCREstimate struct {
+	Provider    string  `json:"provider"`    // limit key, e.g. "google", "ocrspace-free"
+	Files       int     `json:"files"`       // files still to OCR
+	Pages       int     `json:"pages"`       // provider calls, before OCR cache hits
+	PerThousand float64 `json:"perThousand"` // USD per 1000 calls
+	FreeLeft    int     `json:"freeLeft"`    // free calls left this month (-1 = no free tier)
+	Billable    int     `json:"billable"`    // calls beyond the free tier
+	Cost        float64 `json:"cost"`        // USD for the billable calls, consensus engines included
+	BudgetLeft  int     `json:"budgetLeft"`  // calls left in the tightest quota budget (-1 = no budget)
+	Summary     string  `json:"summary"`
+
+	Engines []EngineEstimate `json:"engines,omitempty"` // consensus, escalation and fallback engines
+	MaxCost float64          `json:"maxCost"`           // Cost plus every page escalated or falling back
+}
+
+// EngineEstimate is the share of an estimate that goes to an engine besides
+// the job's provider; the cost is already net of the free calls left
+type EngineEstimate struct {
+	Provider string  `json:"provider"` // limit key
+	Role     string  `json:"role"`     // "consensus", "escalate" or "fallback"
+	Calls    int     `json:"calls"`    // calls, for escalate and fallback an upper bound
+	Billable int     `json:"billable"` // calls beyond the free tier
+	Cost     float64 `json:"cost"`     // USD for the billable calls
+}
+
+// EscalationReport sums up the pages of a run scored for escalation
+type EscalationReport struct {
+	Provider  string  `json:"provider"`  // limit key of the provider weak pages went to
+	Below     int     `json:"below"`     // quality score threshold
+	Pages     int     `json:"pages"`     // pages scored
+	Escalated int     `json:"escalated"` // pages OCR'd again by Provider
+	Cost      float64 `json:"cost"`      // USD for the escalated pages at list price
+	Saved     float64 `json:"saved"`     // USD the other pages would have cost with Provider
+}
+
+// QuotaStatus reports a budget used up during a run
+type QuotaStatus struct {
+	Provider string `json:"provider"` // limit key, e.g. "google", "ocrspace-free"
+	Period   string `json:"period"`   // "day" or "month"
+	Used     int    `json:"used"`
+	Limit    int    `json:"limit"`
+	Action   string `json:"action"`             // "switch" or "stop"
+	SwitchTo string `json:"switchTo,omitempty"` // provider the remaining pages go to
+}
+
+// Session persisted to sessions/<jobId>.json for resume capability, one
+// per interrupted OCR job
 type Session struct {
+	JobID          string   `json:"jobId"`     // from image and output folder
+	UpdatedAt      string   `json:"updatedAt"` // RFC 3339
 	ImageDir       string   `json:"imageDir"`
 	OutputDir      string   `json:"outputDir"`
 	CredFile       string   `json:"credFile"`
@@ -73,6 +219,49 @@ type Session struct {
 	OcrSpacePlan   string   `json:"ocrSpacePlan"`
 	TesseractPath  string   `json:"tesseractPath"`
 	SelectedFiles  []string `json:"selectedFiles"`
+	AutoOrient     bool     `json:"autoOrient"`
+	ImageOnly      bool     `json:"imageOnly"`
+}
+
+// QueuedJob is one OCR job in the job queue, persisted to queue.json
+type QueuedJob struct {
+	ID         string      `json:"id"`
+	Settings   OCRSettings `json:"settings"`
+	Status     string      `json:"status"`               // "pending", "running", "done", "failed" or "cancelled"
+	Error      string      `json:"error,omitempty"`      // why the job failed
+	Owner      int         `json:"owner,omitempty"`      // ID of the process running the job
+	AddedAt    string      `json:"addedAt"`              // RFC 3339
+	FinishedAt string      `json:"finishedAt,omitempty"` // RFC 3339
+}
+
+// FailureRecord is one failed attempt to process a file, persisted to
+// ocr-failures.json in the output folder
+type FailureRecord struct {
+	File     string `json:"file"`
+	Provider string `json:"provider"`
+	Class    string `json:"class"` // "auth", "quota", "timeout", "network", "server", "input", "provider" or "local"
+	Error    string `json:"error"`
+	Time     string `json:"time"`               // RFC 3339
+	Resolved bool   `json:"resolved,omitempty"` // the file succeeded in a later run
+}
+
+// PageResult records how one page was processed, persisted to
+// ocr-results.json in the output folder
+type PageResult struct {
+	File               string   `json:"file"`
+	Page               int      `json:"page,omitempty"`               // 1-based page within a multi-page TIFF
+	Provider           string   `json:"provider"`                     // "none" for images-to-PDF mode
+	Rotation           int      `json:"rotation"`                     // clockwise degrees applied before OCR
+	RotationSource     string   `json:"rotationSource,omitempty"`     // "osd", "heuristic" or "" (not checked)
+	RotationConfidence float64  `json:"rotationConfidence,omitempty"` // detector-specific
+	Skew               float64  `json:"skew"`                         // degrees corrected by deskew
+	TextLayer          bool     `json:"textLayer,omitempty"`          // text stamped onto an image PDF
+	Cached             bool     `json:"cached,omitempty"`             // response came from the OCR cache
+	Confidence         float64  `json:"confidence,omitempty"`         // mean word confidence 0-100 reported by the provider
+	Quality            float64  `json:"quality,omitempty"`            // page quality score 0-100, see pageQuality
+	Escalated          bool     `json:"escalated,omitempty"`          // OCR'd again by the escalation provider for a low score
+	Engines            []string `json:"engines,omitempty"`            // providers voted on a consensus page
+	Disagreements      int      `json:"disagreements,omitempty"`      // words the engines of a consensus page disagreed on
 }
 
 // UsageRecord tracks API calls for one provider+plan on one date
@@ -2,10 +2,14 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -19,14 +23,15 @@ import (
 	"github.com/go-pdf/fpdf"
 	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
-	"google.golang.org/api/option"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
-var filePatternArabic = regexp.MustCompile(`^Page-(\d{3})-(\d{3})(-[a-zA-Z])?\.(JPG|jpg|jpeg|JPEG)$`)
-var filePatternRoman = regexp.MustCompile(`^Page-r-([ivxlcdm]+)-([ivxlcdm]+)(-[a-zA-Z])?\.(JPG|jpg|jpeg|JPEG)$`)
+var filePatternArabic = regexp.MustCompile(`^Page-(\d{3})-(\d{3})(-[a-zA-Z])?\.(?i:jpe?g|png|tiff?|bmp|webp)$`)
+var filePatternRoman = regexp.MustCompile(`^Page-r-([ivxlcdm]+)-([ivxlcdm]+)(-[a-zA-Z])?\.(?i:jpe?g|png|tiff?|bmp|webp)$`)
 
-var filePatternSingleArabic = regexp.MustCompile(`^Page-(\d{3})(-[a-zA-Z])?\.(JPG|jpg|jpeg|JPEG)$`)
-var filePatternSingleRoman = regexp.MustCompile(`^Page-r-([ivxlcdm]+)(-[a-zA-Z])?\.(JPG|jpg|jpeg|JPEG)$`)
+var filePatternSingleArabic = regexp.MustCompile(`^Page-(\d{3})(-[a-zA-Z])?\.(?i:jpe?g|png|tiff?|bmp|webp)$`)
+var filePatternSingleRoman = regexp.MustCompile(`^Page-r-([ivxlcdm]+)(-[a-zA-Z])?\.(?i:jpe?g|png|tiff?|bmp|webp)$`)
 
 func matchesOCRPattern(name string, scanMode string) bool {
 	if scanMode == "single" {
@@ -37,6 +42,12 @@ func matchesOCRPattern(name string, scanMode string) bool {
 
 // StartOCR begins the concurrent OCR pipeline
 func (a *App) StartOCR(settings OCRSettings) string {
+	return a.startOCRRun(func(ctx context.Context) { a.runOCRPipeline(ctx, settings) })
+}
+
+// startOCRRun runs an OCR-tab job in the background. Only one job (OCR or
+// render) runs at a time; StopOCR cancels it.
+func (a *App) startOCRRun(run func(ctx context.Context)) string {
 	a.mu.Lock()
 	if a.ocrRunning {
 		a.mu.Unlock()
@@ -53,6 +64,7 @@ func (a *App) StartOCR(settings OCRSettings) string {
 			a.mu.Lock()
 			a.ocrRunning = false
 			a.cancelOCR = nil
+			a.resumeOCR = nil
 			a.mu.Unlock()
 			if a.onFinished != nil {
 				a.onFinished()
@@ -62,7 +74,7 @@ func (a *App) StartOCR(settings OCRSettings) string {
 				wailsRuntime.EventsEmit(a.ctx, "ocr:finished", nil)
 			}
 		}()
-		a.runOCRPipeline(ctx, settings)
+		run(ctx)
 	}()
 
 	return ""
@@ -77,6 +89,47 @@ func (a *App) StopOCR() {
 	}
 }
 
+// SetOCRPaused pauses or resumes the running OCR job. While paused no new
+// file is started; files already being processed finish and are recorded.
+// StopOCR still stops a paused job.
+func (a *App) SetOCRPaused(paused bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.ocrRunning {
+		return
+	}
+	if paused && a.resumeOCR == nil {
+		a.resumeOCR = make(chan struct{})
+	} else if !paused && a.resumeOCR != nil {
+		close(a.resumeOCR)
+		a.resumeOCR = nil
+	}
+}
+
+// IsOCRPaused returns whether the running OCR job is paused
+func (a *App) IsOCRPaused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.resumeOCR != nil
+}
+
+// waitWhilePaused blocks until OCR is resumed or ctx is cancelled, and
+// returns ctx.Err() in the latter case. Returns at once when not paused.
+func (a *App) waitWhilePaused(ctx context.Context) error {
+	a.mu.Lock()
+	resume := a.resumeOCR
+	a.mu.Unlock()
+	if resume == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // IsOCRRunning returns whether OCR is currently running
 func (a *App) IsOCRRunning() bool {
 	a.mu.Lock()
@@ -84,42 +137,125 @@ func (a *App) IsOCRRunning() bool {
 	return a.ocrRunning
 }
 
-func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) {
-	emitLog := func(filename, message string, index, total int, isError bool) {
-		entry := LogEntry{
-			Filename: filename,
-			Index:    index,
-			Total:    total,
-			Message:  message,
-			IsError:  isError,
+func (a *App) emitOCRLog(filename, message string, index, total int, isError bool) {
+	entry := LogEntry{
+		Filename: filename,
+		Index:    index,
+		Total:    total,
+		Message:  message,
+		IsError:  isError,
+	}
+	if a.onLog != nil {
+		a.onLog(entry)
+	} else {
+		wailsRuntime.EventsEmit(a.ctx, "ocr:log", entry)
+	}
+}
+
+func (a *App) emitOCRProgress(current, total int) {
+	pct := float64(current) / float64(total)
+	update := ProgressUpdate{
+		Current: current,
+		Total:   total,
+		Percent: pct,
+	}
+	if a.onProgress != nil {
+		a.onProgress(update)
+	} else {
+		wailsRuntime.EventsEmit(a.ctx, "ocr:progress", update)
+		wailsRuntime.WindowSetTitle(a.ctx, fmt.Sprintf("OCR Tool — %d%% (%d/%d)", int(pct*100), current, total))
+		taskbar.SetProgress(pct * 100)
+	}
+}
+
+// ocrPlan is what an OCR run does with a job's selected files
+type ocrPlan struct {
+	selected    int             // selected files the run can take
+	files       []string        // files taking part, sorted
+	remaining   []string        // files still to do
+	textLayer   map[string]bool // image PDFs that get a text layer added
+	processed   map[string]bool // files done in an earlier run
+	skippedPDFs int             // PDF inputs left out in images-to-PDF mode
+}
+
+// planOCRRun works out which selected files are still to do. Files recorded
+// in the job's session or with an output already written are done, except
+// image PDFs from images-to-PDF mode, which get a text layer added. In retry
+// mode only the files still failed take part, finished or not.
+func (a *App) planOCRRun(settings OCRSettings, results *pageResults, failures *failureLog) ocrPlan {
+	plan := ocrPlan{
+		textLayer: make(map[string]bool),
+		processed: make(map[string]bool),
+	}
+	for _, f := range settings.SelectedFiles {
+		// A PDF input is already a PDF: only OCR has anything to add
+		if settings.ImageOnly && isPDFInput(f) {
+			plan.skippedPDFs++
+			continue
 		}
-		if a.onLog != nil {
-			a.onLog(entry)
-		} else {
-			wailsRuntime.EventsEmit(a.ctx, "ocr:log", entry)
+		plan.files = append(plan.files, f)
+	}
+	sort.Strings(plan.files)
+	plan.selected = len(plan.files)
+
+	if prev := a.sessionFor(settings.ImageDir, settings.OutputDir); prev != nil && prev.ImageOnly == settings.ImageOnly {
+		for _, f := range prev.ProcessedFiles {
+			plan.processed[f] = true
 		}
 	}
 
-	emitProgress := func(current, total int) {
-		pct := float64(current) / float64(total)
-		update := ProgressUpdate{
-			Current: current,
-			Total:   total,
-			Percent: pct,
+	if settings.RetryFailed {
+		failedSet := make(map[string]bool)
+		for _, r := range failures.unresolved() {
+			failedSet[r.File] = true
 		}
-		if a.onProgress != nil {
-			a.onProgress(update)
-		} else {
-			wailsRuntime.EventsEmit(a.ctx, "ocr:progress", update)
-			wailsRuntime.WindowSetTitle(a.ctx, fmt.Sprintf("OCR Tool — %d%% (%d/%d)", int(pct*100), current, total))
-			taskbar.SetProgress(pct * 100)
+		var retry []string
+		for _, f := range plan.files {
+			if failedSet[filepath.Base(f)] {
+				retry = append(retry, f)
+			}
+		}
+		plan.files = retry
+	}
+
+	for _, f := range plan.files {
+		base := filepath.Base(f)
+		outputPath := filepath.Join(settings.OutputDir, ocrOutputName(base))
+
+		if plan.processed[base] && !settings.RetryFailed {
+			continue
+		}
+		if _, err := os.Stat(outputPath); err == nil {
+			if !settings.ImageOnly && results.imageOnly(base) {
+				plan.textLayer[base] = true
+				plan.remaining = append(plan.remaining, f)
+				continue
+			}
+			if !settings.RetryFailed {
+				plan.processed[base] = true
+				continue
+			}
 		}
+		plan.remaining = append(plan.remaining, f)
+	}
+	return plan
+}
+
+// runOCRPipeline runs one OCR job. It returns ctx.Err() when stopped, and an
+// error when the job could not run or some files failed; every error has
+// already been logged.
+func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) error {
+	emitLog := a.emitOCRLog
+	emitProgress := a.emitOCRProgress
+	fail := func(format string, args ...any) error {
+		err := fmt.Errorf(format, args...)
+		emitLog("", err.Error(), 0, 0, true)
+		return err
 	}
 
 	// Create output directory
 	if err := os.MkdirAll(settings.OutputDir, 0755); err != nil {
-		emitLog("", fmt.Sprintf("Cannot create output directory: %v", err), 0, 0, true)
-		return
+		return fail("Cannot create output directory: %v", err)
 	}
 
 	// Log scan mode
@@ -128,40 +264,36 @@ func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) {
 		modeLabel = "single-page"
 	}
 	emitLog("", fmt.Sprintf("Scan mode: %s", modeLabel), 0, 0, false)
-
-	// Use selected files from frontend
-	files := make([]string, len(settings.SelectedFiles))
-	copy(files, settings.SelectedFiles)
-	sort.Strings(files)
-
-	if len(files) == 0 {
-		emitLog("", "No files selected", 0, 0, true)
-		return
+	if settings.ImageOnly {
+		emitLog("", "Images to PDF: no OCR", 0, 0, false)
 	}
 
-	// Load existing session to find already processed files
-	processedSet := make(map[string]bool)
-	if a.session != nil && a.session.ImageDir == settings.ImageDir {
-		for _, f := range a.session.ProcessedFiles {
-			processedSet[f] = true
-		}
-	}
+	// Per-page results (orientation decisions, image-only PDFs etc.)
+	results := loadPageResults(settings.OutputDir)
+	defer results.save()
 
-	// Filter out already processed
-	var remaining []string
-	for _, f := range files {
-		base := filepath.Base(f)
-		pdfName := strings.TrimSuffix(base, filepath.Ext(base)) + ".pdf"
-		outputPath := filepath.Join(settings.OutputDir, pdfName)
+	// Failures of earlier runs; retry mode runs only the files still failed
+	failures := loadFailureLog(settings.OutputDir)
+	defer failures.save()
 
-		if processedSet[base] {
-			continue
+	plan := a.planOCRRun(settings, results, failures)
+	files, remaining, textLayer, processedSet := plan.files, plan.remaining, plan.textLayer, plan.processed
+	if plan.skippedPDFs > 0 {
+		emitLog("", fmt.Sprintf("Skipping %d PDF input(s) in images-to-PDF mode", plan.skippedPDFs), 0, 0, false)
+	}
+	if plan.selected == 0 {
+		return fail("No files selected")
+	}
+	if settings.RetryFailed {
+		if len(files) == 0 {
+			emitLog("", "No failed files to retry", 0, 0, false)
+			return nil
 		}
-		if _, err := os.Stat(outputPath); err == nil {
-			processedSet[base] = true
-			continue
+		msg := fmt.Sprintf("Retrying %d failed files", len(files))
+		if !settings.ImageOnly {
+			msg += " with " + providerName(settings.Provider)
 		}
-		remaining = append(remaining, f)
+		emitLog("", msg, 0, 0, false)
 	}
 
 	totalFiles := len(files)
@@ -169,29 +301,63 @@ func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) {
 
 	emitLog("", fmt.Sprintf("Found %d matching files, %d already processed, %d remaining",
 		totalFiles, alreadyDone, len(remaining)), 0, totalFiles, false)
+	if len(textLayer) > 0 {
+		emitLog("", fmt.Sprintf("%d image PDF(s) will get a text layer", len(textLayer)), 0, totalFiles, false)
+	}
 
 	if len(remaining) == 0 {
 		emitLog("", "All files already processed", 0, totalFiles, false)
 		if settings.MergePDF {
 			a.mergePDFs(settings.OutputDir, settings.MergeFilename)
 		}
-		return
+		return nil
+	}
+
+	engines := consensusEngines(settings)
+	if chain := providerChain(settings); len(chain) > 1 && engines == nil && !settings.ImageOnly {
+		emitLog("", "Fallback providers: "+strings.Join(chain, " → "), 0, 0, false)
+	}
+	if engines != nil && !settings.ImageOnly {
+		emitLog("", "Consensus: every page OCR'd by "+strings.Join(engines, ", ")+", words voted by confidence", 0, 0, false)
+	}
+	esc, err := a.escalationFor(settings)
+	if err != nil {
+		emitLog("", fmt.Sprintf("%v; checking words by their letters instead", err), 0, 0, false)
+	}
+	if esc != nil && !settings.ImageOnly {
+		emitLog("", fmt.Sprintf("Escalation: pages scored below %.0f go to %s", esc.below, esc.provider), 0, 0, false)
+	}
+	if usesProvider(settings, "tesseract") && settings.TesseractPath == "" {
+		settings.TesseractPath = a.DetectTesseract()
 	}
 
-	// Create Vision API client (only for Google provider)
+	// Create Vision API client (only when Google may be called)
 	var visionClient *vision.ImageAnnotatorClient
-	if settings.Provider == "google" {
-		client, err := vision.NewImageAnnotatorClient(ctx, option.WithCredentialsFile(settings.CredFile))
+	if usesProvider(settings, "google") && !settings.ImageOnly {
+		client, err := a.newVisionClient(ctx, settings)
 		if err != nil {
-			emitLog("", fmt.Sprintf("Cannot create Vision API client: %v", err), 0, 0, true)
-			return
+			return fail("Cannot create Vision API client: %v", err)
 		}
 		defer client.Close()
 		visionClient = client
 	}
 
+	if usesProvider(settings, "ocrspace") && !settings.ImageOnly {
+		if _, err := a.ocrSpaceClientFor(settings); err != nil {
+			return fail("OCR.space: %v", err)
+		}
+		endpoint := a.ocrSpaceURLFor(settings)
+		if u, err := url.Parse(endpoint); err != nil || u.Host == "" {
+			return fail("OCR.space: invalid endpoint %q", endpoint)
+		}
+		if endpoint != defaultOcrSpaceURL {
+			emitLog("", "OCR.space endpoint: "+endpoint, 0, 0, false)
+		}
+	}
+
 	// Initialize session
 	session := &Session{
+		JobID:          sessionJobID(settings.ImageDir, settings.OutputDir),
 		ImageDir:       settings.ImageDir,
 		OutputDir:      settings.OutputDir,
 		CredFile:       settings.CredFile,
@@ -208,6 +374,8 @@ func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) {
 		OcrSpacePlan:   settings.OcrSpacePlan,
 		TesseractPath:  settings.TesseractPath,
 		SelectedFiles:  settings.SelectedFiles,
+		AutoOrient:     settings.AutoOrient,
+		ImageOnly:      settings.ImageOnly,
 	}
 	for f := range processedSet {
 		session.ProcessedFiles = append(session.ProcessedFiles, f)
@@ -237,41 +405,96 @@ func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) {
 		sessionMu.Unlock()
 	}
 
+	if settings.AutoOrient && !settings.ImageOnly {
+		emitLog("", "Auto orientation: on", 0, 0, false)
+	}
+
 	// Resolve CJK font path once
 	fontPath := a.cjkFontPath()
 	if fontPath != "" {
 		emitLog("", fmt.Sprintf("Using CJK font: %s", fontPath), 0, 0, false)
 	}
 
-	// Concurrent worker pool
+	// Concurrent worker pool. Provider requests are paced by the provider's
+	// rate limits, and the pool shrinks when the provider throttles and grows
+	// back while requests succeed. The automatic worker count starts within
+	// the provider's MaxConcurrency.
 	concurrency := settings.Concurrency
 	if concurrency < 1 {
-		concurrency = 1
+		concurrency = 5
+		if settings.Provider == "tesseract" || settings.ImageOnly {
+			concurrency = runtime.NumCPU()
+		}
+	}
+	ceiling := concurrency
+	if !settings.ImageOnly {
+		limit := a.providerLimitFor(settings)
+		if settings.Concurrency < 1 && limit.MaxConcurrency > 0 {
+			concurrency = min(concurrency, limit.MaxConcurrency)
+		}
+		// The ceiling only bounds ramping up: a worker count chosen
+		// explicitly is kept even above it
+		ceiling = max(concurrency, limit.MaxConcurrency)
+		emitLog("", fmt.Sprintf("%s limits: %s", providerName(settings.Provider), describeLimit(limit)), 0, 0, false)
 	}
-	if concurrency > 10 {
-		concurrency = 10
+	workers := newConcurrencyController(concurrency, ceiling, func(limit int, reason string) {
+		emitLog("", fmt.Sprintf("Concurrency %d: %s", limit, reason), 0, 0, false)
+	})
+	var pace *pacing
+	if !settings.ImageOnly {
+		pace = &pacing{workers: workers, escalation: esc}
+		// Pages of concurrent workers share Vision calls
+		if n := a.visionBatchFor(settings); visionClient != nil && n > 1 {
+			pace.vision = newVisionBatcher(ctx, visionClient, workers, n)
+			emitLog("", fmt.Sprintf("Google Vision: up to %d images per request", n), 0, 0, false)
+		}
+		ctx = withPacing(ctx, pace)
 	}
-
-	sem := make(chan struct{}, concurrency)
 	var wg sync.WaitGroup
-	var processed int64
+	var processed, failed int64
+	var quotaStop atomic.Pointer[budgetError] // set once a budget stops the run
+
+	stopped := func() error {
+		emitLog("", "OCR stopped by user", 0, totalFiles, false)
+		wg.Wait()
+		sessionMu.Lock()
+		a.saveSession(session)
+		sessionMu.Unlock()
+		return ctx.Err()
+	}
 
 	for i, filePath := range remaining {
-		select {
-		case <-ctx.Done():
-			emitLog("", "OCR stopped by user", 0, totalFiles, false)
+		if workers.acquire(ctx) != nil {
+			return stopped()
+		}
+		if quotaStop.Load() != nil {
+			workers.release()
+			break
+		}
+
+		// Paused: start nothing new, let the files in flight finish and
+		// record them, then wait here for resume
+		if a.IsOCRPaused() {
+			workers.release()
 			wg.Wait()
 			sessionMu.Lock()
 			a.saveSession(session)
+			sessionDirty = false
 			sessionMu.Unlock()
-			return
-		case sem <- struct{}{}:
+			emitLog("", fmt.Sprintf("OCR paused, %d files left", len(remaining)-i), 0, totalFiles, false)
+			if a.waitWhilePaused(ctx) != nil {
+				return stopped()
+			}
+			emitLog("", "OCR resumed", 0, totalFiles, false)
+			if workers.acquire(ctx) != nil {
+				return stopped()
+			}
 		}
 
 		wg.Add(1)
 		go func(idx int, fp string) {
 			defer wg.Done()
-			defer func() { <-sem }()
+			defer workers.release()
 
 			baseName := filepath.Base(fp)
 
@@ -281,14 +504,62 @@ func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) {
 			default:
 			}
 
-			err := a.processOneImage(ctx, visionClient, fp, settings, fontPath)
+			outputPath := filepath.Join(settings.OutputDir, ocrOutputName(baseName))
+			var pageResults []PageResult
+			var err error
+			switch {
+			case settings.ImageOnly:
+				pageResults, err = generateImagePDF(outputPath, fp, settings.ScanMode)
+			case textLayer[baseName]:
+				pageResults, err = a.addTextLayer(ctx, visionClient, fp, outputPath, settings, fontPath)
+			default:
+				pageResults, err = a.processOneImage(ctx, visionClient, fp, settings, fontPath)
+			}
 
 			cur := int(atomic.AddInt64(&processed, 1)) + alreadyDone
 
-			if err != nil {
+			var budgetErr *budgetError
+			if err != nil && ctx.Err() != nil {
+				// Cut off by Stop: not a failure, the file runs again on resume
+				emitLog(baseName, "Stopped", cur, totalFiles, false)
+			} else if errors.As(err, &budgetErr) {
+				// Out of budget: not a failure either, the file waits for
+				// the next run
+				quotaStop.CompareAndSwap(nil, budgetErr)
+				pace.quota.report(a, budgetErr, "")
+				emitLog(baseName, "Stopped: "+budgetErr.Error(), cur, totalFiles, false)
+			} else if err != nil {
+				atomic.AddInt64(&failed, 1)
+				failures.add(baseName, failedProvider(err, settings.Provider), err)
 				emitLog(baseName, fmt.Sprintf("Error: %v", err), cur, totalFiles, true)
 			} else {
-				emitLog(baseName, "OK", cur, totalFiles, false)
+				failures.resolve(baseName)
+				msg := "OK"
+				if len(pageResults) > 1 {
+					msg = fmt.Sprintf("OK (%d pages)", len(pageResults))
+				}
+				if textLayer[baseName] {
+					msg += ", text layer added"
+				}
+				cachedPages := 0
+				for _, r := range pageResults {
+					if r.Cached {
+						cachedPages++
+					}
+				}
+				if cachedPages == len(pageResults) && cachedPages > 0 {
+					msg += ", from cache"
+				} else if cachedPages > 0 {
+					msg += fmt.Sprintf(", %d pages from cache", cachedPages)
+				}
+				msg += fallbackSummary(pageResults, settings.Provider)
+				msg += escalationSummary(pageResults)
+				msg += consensusSummary(pageResults)
+				for _, r := range pageResults {
+					msg += orientSummary(r)
+					results.update(r)
+				}
+				emitLog(baseName, msg, cur, totalFiles, false)
 				markProcessed(baseName)
 			}
 
@@ -302,13 +573,43 @@ func (a *App) runOCRPipeline(ctx context.Context, settings OCRSettings) {
 	a.saveSession(session)
 	sessionMu.Unlock()
 
-	emitLog("", fmt.Sprintf("OCR complete! Processed %d files", atomic.LoadInt64(&processed)), totalFiles, totalFiles, false)
+	// Stopped while the last files were in flight: keep the session
+	if ctx.Err() != nil {
+		emitLog("", "OCR stopped by user", 0, totalFiles, false)
+		return ctx.Err()
+	}
+	if pace != nil {
+		a.emitEscalationReport(pace.escalation)
+	}
+	// A used-up budget keeps the session too, to go on once it resets
+	if e := quotaStop.Load(); e != nil {
+		a.emitFailureReport(failures.unresolved())
+		return fail("OCR stopped: %v; the files left run on resume", e)
+	}
+
+	if settings.ImageOnly {
+		emitLog("", fmt.Sprintf("Image PDFs complete! Processed %d files", atomic.LoadInt64(&processed)), totalFiles, totalFiles, false)
+	} else {
+		emitLog("", fmt.Sprintf("OCR complete! Processed %d files", atomic.LoadInt64(&processed)), totalFiles, totalFiles, false)
+	}
+
+	// Keep the OCR cache within its size limit
+	if removed, freed, err := pruneOCRCache(a.ocrCacheDir(), a.ocrCacheMaxBytes(), 0); err == nil && removed > 0 {
+		emitLog("", fmt.Sprintf("OCR cache: evicted %d old entries (%.1f MB)", removed, float64(freed)/(1<<20)), totalFiles, totalFiles, false)
+	}
 
 	if settings.MergePDF {
 		a.mergePDFs(settings.OutputDir, settings.MergeFilename)
 	}
 
-	a.ClearSession()
+	a.ClearSession(session.JobID)
+
+	a.emitFailureReport(failures.unresolved())
+
+	if n := atomic.LoadInt64(&failed); n > 0 {
+		return fmt.Errorf("%d of %d files failed", n, len(remaining))
+	}
+	return nil
 }
 
 func (a *App) cjkFontPath() string {
@@ -334,48 +635,146 @@ func (a *App) cjkFontPath() string {
 	return ""
 }
 
-func (a *App) processOneImage(ctx context.Context, client *vision.ImageAnnotatorClient, filePath string, settings OCRSettings, fontPath string) error {
+func (a *App) processOneImage(ctx context.Context, client *vision.ImageAnnotatorClient, filePath string, settings OCRSettings, fontPath string) ([]PageResult, error) {
 	baseName := filepath.Base(filePath)
-	pdfName := strings.TrimSuffix(baseName, filepath.Ext(baseName)) + ".pdf"
-	outputPath := filepath.Join(settings.OutputDir, pdfName)
+	outputPath := filepath.Join(settings.OutputDir, ocrOutputName(baseName))
+
+	// Image-only PDFs get a text layer instead of a text-only PDF
+	if isPDFInput(filePath) {
+		return a.processOnePDF(ctx, client, filePath, outputPath, settings, fontPath)
+	}
 
-	// OCR.space provider: simple text extraction (no coordinate-based splitting)
-	if settings.Provider == "ocrspace" {
-		text, err := a.callOcrSpace(filePath, settings)
+	// Multi-page TIFFs are OCR'd page by page into one PDF
+	pageCount, err := ocrPageCount(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	scans := make([]scanText, 0, pageCount)
+	results := make([]PageResult, 0, pageCount)
+	for page := 0; page < pageCount; page++ {
+		result := PageResult{File: baseName, Provider: settings.Provider}
+		if pageCount > 1 {
+			result.Page = page + 1
+		}
+		scan, err := a.ocrOnePage(ctx, client, filePath, page, pageCount, settings, &result)
 		if err != nil {
-			return err
+			if pageCount > 1 {
+				return nil, fmt.Errorf("page %d: %w", page+1, err)
+			}
+			return nil, err
 		}
-		if settings.ScanMode == "single" {
-			label := pageLabelFromFilenameSingle(baseName)
-			return generateSinglePagePDF(outputPath, text, label, fontPath)
+		scans = append(scans, scan)
+		results = append(results, result)
+	}
+
+	return results, generateOCRPDF(outputPath, baseName, settings.ScanMode, scans, fontPath)
+}
+
+// ocrPageWith converts one page to a file settings.Provider can read,
+// optionally fixes its orientation (recorded in result), and runs the
+// provider.
+func (a *App) ocrPageWith(ctx context.Context, client *vision.ImageAnnotatorClient, filePath string, page, pageCount int, settings OCRSettings, result *PageResult) (scanText, error) {
+	srcPath, cleanup, err := ocrPageFile(filePath, page, pageCount, settings.Provider)
+	if err != nil {
+		return scanText{}, err
+	}
+	defer cleanup()
+
+	// Auto-orient: OCR a rotated/deskewed temp copy
+	if settings.AutoOrient {
+		tesseractPath := settings.TesseractPath
+		if tesseractPath == "" {
+			tesseractPath = a.DetectTesseract()
+		}
+		path, fix, cleanup, err := orientedImageFile(ctx, srcPath, tesseractPath, settings.Languages)
+		if err != nil {
+			return scanText{}, fmt.Errorf("orientation: %w", err)
+		}
+		defer cleanup()
+		srcPath = path
+		result.Rotation = fix.Rotation
+		result.RotationSource = fix.Source
+		result.RotationConfidence = math.Round(fix.Confidence*100) / 100
+		if math.Abs(fix.Skew) >= orientMinSkew {
+			result.Skew = fix.Skew
 		}
-		// Dual-page: OCR.space doesn't provide coordinates, put all text on both pages
-		leftLabel, rightLabel := pageLabelsFromFilename(baseName)
-		return generatePDFWithAnnotation(outputPath, text, "", leftLabel, rightLabel, fontPath)
 	}
 
-	// Tesseract provider: local OCR, no coordinates
-	if settings.Provider == "tesseract" {
-		text, err := a.callTesseract(filePath, settings)
+	// Input already OCR'd with the same provider and settings, in any book
+	// or run, is answered from the cache
+	cacheDir := a.ocrCacheDir()
+	cacheKey := ""
+	if !settings.NoCache {
+		if cacheKey, err = ocrCacheKey(srcPath, settings, a.cacheBackend(settings)); err != nil {
+			return scanText{}, fmt.Errorf("cache: %w", err)
+		}
+	}
+	raw, cached := loadCachedResponse(cacheDir, settings.Provider, cacheKey)
+	result.Cached = cached
+	if !cached {
+		// Transient errors (rate limits, timeouts, 5xx) are retried with
+		// backoff; every attempt waits for the provider's rate limit
+		call := func() ([]byte, error) {
+			release, err := a.reserveQuota(limitKey(settings))
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+			switch settings.Provider {
+			case "ocrspace":
+				return a.callOcrSpace(ctx, srcPath, settings)
+			case "tesseract":
+				return a.callTesseract(ctx, srcPath, settings)
+			}
+			return a.callGoogleVision(ctx, client, srcPath, settings)
+		}
+		raw, err = withRetry(ctx, a.retryPolicyFor(settings), a.paced(ctx, settings, call), func(n int, wait time.Duration, class string, err error) {
+			a.emitOCRLog(result.File, fmt.Sprintf("%s: %s error, retry %d in %.1fs: %v",
+				pageResultLabel(*result), class, n, wait.Seconds(), err), 0, 0, false)
+		})
 		if err != nil {
-			return err
+			return scanText{}, &providerError{settings.Provider, err}
 		}
-		if settings.ScanMode == "single" {
-			label := pageLabelFromFilenameSingle(baseName)
-			return generateSinglePagePDF(outputPath, text, label, fontPath)
+		if cacheKey != "" {
+			// A failed cache write only costs a provider call next time
+			storeCachedResponse(cacheDir, settings.Provider, cacheKey, raw)
 		}
-		leftLabel, rightLabel := pageLabelsFromFilename(baseName)
-		return generatePDFWithAnnotation(outputPath, text, "", leftLabel, rightLabel, fontPath)
 	}
 
-	// Google Vision provider
-	return a.processOneImageGoogle(ctx, client, filePath, outputPath, baseName, settings, fontPath)
+	// Keep the response so the output can be rendered again without OCR
+	if err := saveRawResponse(settings.OutputDir, *result, raw); err != nil {
+		return scanText{}, err
+	}
+	return parseRawResponse(settings.Provider, raw, settings.ScanMode)
+}
+
+// scanText is the recognized text of one scanned image. In dual-page mode
+// Left and Right hold the two halves of the spread; single-page scans and
+// providers without coordinates only fill Left. Blocks is only set by
+// providers that report coordinates. Confidence is the mean word
+// confidence (0-100), -1 when the provider reports none; Words are the
+// words in reading order, for consensus voting.
+type scanText struct {
+	Left, Right string
+	Blocks      []textBlock
+	Confidence  float64
+	Words       []ocrWord
 }
 
-func (a *App) processOneImageGoogle(ctx context.Context, client *vision.ImageAnnotatorClient, filePath, outputPath, baseName string, settings OCRSettings, fontPath string) error {
+// textBlock is a recognized block with its bounding box as fractions (0-1)
+// of the image width and height.
+type textBlock struct {
+	Text           string
+	X0, Y0, X1, Y1 float64
+}
+
+// callGoogleVision sends an image to the Vision API and returns the raw
+// response as JSON; visionScanText extracts the text from it
+func (a *App) callGoogleVision(ctx context.Context, client *vision.ImageAnnotatorClient, filePath string, settings OCRSettings) ([]byte, error) {
 	imgData, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("read: %w", err)
+		return nil, fmt.Errorf("read: %w", err)
 	}
 
 	image := &visionpb.Image{Content: imgData}
@@ -389,29 +788,59 @@ func (a *App) processOneImageGoogle(ctx context.Context, client *vision.ImageAnn
 		},
 	}
 
-	batchResp, err := client.BatchAnnotateImages(ctx, &visionpb.BatchAnnotateImagesRequest{
-		Requests: []*visionpb.AnnotateImageRequest{req},
-	})
+	var resp *visionpb.AnnotateImageResponse
+	if p := pacingFrom(ctx); p != nil && p.vision != nil {
+		resp, err = p.vision.annotate(ctx, req, len(imgData))
+	} else {
+		var batchResp *visionpb.BatchAnnotateImagesResponse
+		batchResp, err = client.BatchAnnotateImages(ctx, &visionpb.BatchAnnotateImagesRequest{
+			Requests: []*visionpb.AnnotateImageRequest{req},
+		})
+		switch {
+		case err != nil:
+		case len(batchResp.Responses) != 1:
+			err = fmt.Errorf("%d responses for 1 image", len(batchResp.Responses))
+		default:
+			resp = batchResp.Responses[0]
+		}
+	}
 
 	imgData = nil
 	_ = imgData
 
 	if err != nil {
-		return fmt.Errorf("API: %w", err)
+		return nil, fmt.Errorf("API: %w", err)
 	}
 
-	resp := batchResp.Responses[0]
 	if resp.Error != nil {
-		return fmt.Errorf("API error: %s", resp.Error.Message)
+		return nil, fmt.Errorf("API error: %w", status.ErrorProto(resp.Error))
 	}
 
 	a.RecordApiCall("google", "")
 
-	if settings.ScanMode == "single" {
-		if resp.FullTextAnnotation == nil {
-			label := pageLabelFromFilenameSingle(baseName)
-			return generateSinglePagePDF(outputPath, "", label, fontPath)
-		}
+	data, err := protojson.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("encode response: %w", err)
+	}
+	return data, nil
+}
+
+// visionScanText extracts the text of a stored Vision response. In dual
+// mode blocks are split into the left and right page at the image midline.
+func visionScanText(data []byte, scanMode string) (scanText, error) {
+	resp := &visionpb.AnnotateImageResponse{}
+	if err := protojson.Unmarshal(data, resp); err != nil {
+		return scanText{}, fmt.Errorf("parse response: %w", err)
+	}
+
+	if resp.FullTextAnnotation == nil {
+		return scanText{Confidence: -1}, nil
+	}
+
+	blocks := visionTextBlocks(resp.FullTextAnnotation)
+	conf := visionConfidence(resp.FullTextAnnotation)
+
+	if scanMode == "single" {
 		var allTexts []string
 		for _, page := range resp.FullTextAnnotation.Pages {
 			for _, block := range page.Blocks {
@@ -421,14 +850,12 @@ func (a *App) processOneImageGoogle(ctx context.Context, client *vision.ImageAnn
 				}
 			}
 		}
-		text := strings.Join(allTexts, "\n\n")
-		label := pageLabelFromFilenameSingle(baseName)
-		return generateSinglePagePDF(outputPath, text, label, fontPath)
-	}
-
-	if resp.FullTextAnnotation == nil {
-		leftLabel, rightLabel := pageLabelsFromFilename(baseName)
-		return generatePDFWithAnnotation(outputPath, "", "", leftLabel, rightLabel, fontPath)
+		return scanText{
+			Left:       strings.Join(allTexts, "\n\n"),
+			Blocks:     blocks,
+			Confidence: conf,
+			Words:      visionWords(resp.FullTextAnnotation, 0),
+		}, nil
 	}
 
 	maxX := float32(0)
@@ -467,11 +894,59 @@ func (a *App) processOneImageGoogle(ctx context.Context, client *vision.ImageAnn
 		}
 	}
 
-	leftText := strings.Join(leftTexts, "\n\n")
-	rightText := strings.Join(rightTexts, "\n\n")
+	return scanText{
+		Left:       strings.Join(leftTexts, "\n\n"),
+		Right:      strings.Join(rightTexts, "\n\n"),
+		Blocks:     blocks,
+		Confidence: conf,
+		Words:      visionWords(resp.FullTextAnnotation, midX),
+	}, nil
+}
+
+// visionConfidence is the mean confidence of the recognized words, -1 when
+// there are none
+func visionConfidence(ann *visionpb.TextAnnotation) float64 {
+	sum, n := 0.0, 0
+	for _, page := range ann.Pages {
+		for _, block := range page.Blocks {
+			for _, para := range block.Paragraphs {
+				for _, word := range para.Words {
+					sum += float64(word.Confidence)
+					n++
+				}
+			}
+		}
+	}
+	if n == 0 {
+		return -1
+	}
+	return sum / float64(n) * 100
+}
 
-	leftLabel, rightLabel := pageLabelsFromFilename(baseName)
-	return generatePDFWithAnnotation(outputPath, leftText, rightText, leftLabel, rightLabel, fontPath)
+// visionTextBlocks returns every non-empty block with its bounding box
+// normalized by the page size.
+func visionTextBlocks(ann *visionpb.TextAnnotation) []textBlock {
+	var blocks []textBlock
+	for _, page := range ann.Pages {
+		if page.Width == 0 || page.Height == 0 {
+			continue
+		}
+		w, h := float64(page.Width), float64(page.Height)
+		for _, block := range page.Blocks {
+			text := extractBlockText(block)
+			if text == "" || block.BoundingBox == nil || len(block.BoundingBox.Vertices) == 0 {
+				continue
+			}
+			b := textBlock{Text: text, X0: 1, Y0: 1}
+			for _, v := range block.BoundingBox.Vertices {
+				x, y := float64(v.X)/w, float64(v.Y)/h
+				b.X0, b.Y0 = min(b.X0, x), min(b.Y0, y)
+				b.X1, b.Y1 = max(b.X1, x), max(b.Y1, y)
+			}
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
 }
 
 func setupPDFFont(pdf *fpdf.Fpdf, fontPath string) (string, func(string) string) {
@@ -495,6 +970,54 @@ func blockCenterX(block *visionpb.Block) float32 {
 	return sumX / float32(len(block.BoundingBox.Vertices))
 }
 
+// visionWords returns the words of a Vision annotation in reading order with
+// their confidences. With midX > 0 (dual mode) each word is marked with the
+// half of the spread its block lies in.
+func visionWords(ann *visionpb.TextAnnotation, midX float32) []ocrWord {
+	var words []ocrWord
+	for _, page := range ann.Pages {
+		for _, block := range page.Blocks {
+			half := 0
+			if midX > 0 {
+				half = 1
+				if blockCenterX(block) >= midX {
+					half = 2
+				}
+			}
+			for _, para := range block.Paragraphs {
+				for _, word := range para.Words {
+					w := ocrWord{Conf: float64(word.Confidence), Half: half}
+					for _, s := range word.Symbols {
+						w.Text += s.Text
+						if s.Property == nil || s.Property.DetectedBreak == nil {
+							continue
+						}
+						switch s.Property.DetectedBreak.Type {
+						case visionpb.TextAnnotation_DetectedBreak_SPACE,
+							visionpb.TextAnnotation_DetectedBreak_SURE_SPACE:
+							w.Sep = " "
+						case visionpb.TextAnnotation_DetectedBreak_EOL_SURE_SPACE,
+							visionpb.TextAnnotation_DetectedBreak_HYPHEN,
+							visionpb.TextAnnotation_DetectedBreak_LINE_BREAK:
+							w.Sep = "\n"
+						}
+					}
+					if w.Text != "" {
+						words = append(words, w)
+					}
+				}
+				if n := len(words); n > 0 && words[n-1].Sep == "" {
+					words[n-1].Sep = "\n"
+				}
+			}
+			if n := len(words); n > 0 {
+				words[n-1].Sep = "\n\n"
+			}
+		}
+	}
+	return words
+}
+
 func extractBlockText(block *visionpb.Block) string {
 	var parts []string
 	for _, para := range block.Paragraphs {
@@ -541,36 +1064,6 @@ func pageLabelsFromFilename(basename string) (left, right string) {
 	return "", ""
 }
 
-func generatePDFWithAnnotation(outputPath, leftText, rightText, leftLabel, rightLabel, fontPath string) error {
-	pdf := fpdf.New("P", "mm", "A4", "")
-	pdf.SetAutoPageBreak(false, 0)
-	fontName, tr := setupPDFFont(pdf, fontPath)
-	margin := 15.0
-	cellW := 210.0 - margin*2
-
-	pdf.AddPage()
-	if leftLabel != "" {
-		pdf.SetFont(fontName, "B", 10)
-		pdf.SetXY(margin, 5)
-		pdf.Cell(cellW, 5, leftLabel)
-	}
-	pdf.SetFont(fontName, "", 12)
-	pdf.SetXY(margin, margin)
-	pdf.MultiCell(cellW, 5, tr(leftText), "", "L", false)
-
-	pdf.AddPage()
-	if rightLabel != "" {
-		pdf.SetFont(fontName, "B", 10)
-		pdf.SetXY(margin, 5)
-		pdf.Cell(cellW, 5, rightLabel)
-	}
-	pdf.SetFont(fontName, "", 12)
-	pdf.SetXY(margin, margin)
-	pdf.MultiCell(cellW, 5, tr(rightText), "", "L", false)
-
-	return pdf.OutputFileAndClose(outputPath)
-}
-
 func pageLabelFromFilenameSingle(basename string) string {
 	if m := filePatternSingleRoman.FindStringSubmatch(basename); m != nil {
 		return "Page " + m[1]
@@ -585,22 +1078,42 @@ func pageLabelFromFilenameSingle(basename string) string {
 	return ""
 }
 
-func generateSinglePagePDF(outputPath, text, label, fontPath string) error {
+// generateOCRPDF writes the text of every scan in a file to one PDF. Dual
+// mode produces a left and a right page per scan. Labels come from the file
+// name; scans of a multi-page file get a "(n/total)" suffix.
+func generateOCRPDF(outputPath, baseName, scanMode string, scans []scanText, fontPath string) error {
 	pdf := fpdf.New("P", "mm", "A4", "")
 	pdf.SetAutoPageBreak(false, 0)
 	fontName, tr := setupPDFFont(pdf, fontPath)
 	margin := 15.0
 	cellW := 210.0 - margin*2
 
-	pdf.AddPage()
-	if label != "" {
-		pdf.SetFont(fontName, "B", 10)
-		pdf.SetXY(margin, 5)
-		pdf.Cell(cellW, 5, label)
+	addPage := func(label, text string) {
+		pdf.AddPage()
+		if label != "" {
+			pdf.SetFont(fontName, "B", 10)
+			pdf.SetXY(margin, 5)
+			pdf.Cell(cellW, 5, label)
+		}
+		pdf.SetFont(fontName, "", 12)
+		pdf.SetXY(margin, margin)
+		pdf.MultiCell(cellW, 5, tr(text), "", "L", false)
+	}
+
+	for i, scan := range scans {
+		suffix := ""
+		if len(scans) > 1 {
+			suffix = fmt.Sprintf(" (%d/%d)", i+1, len(scans))
+		}
+		if scanMode == "single" {
+			label := pageLabelFromFilenameSingle(baseName)
+			addPage(strings.TrimSpace(label+suffix), scan.Left)
+			continue
+		}
+		leftLabel, rightLabel := pageLabelsFromFilename(baseName)
+		addPage(strings.TrimSpace(leftLabel+suffix), scan.Left)
+		addPage(strings.TrimSpace(rightLabel+suffix), scan.Right)
 	}
-	pdf.SetFont(fontName, "", 12)
-	pdf.SetXY(margin, margin)
-	pdf.MultiCell(cellW, 5, tr(text), "", "L", false)
 
 	return pdf.OutputFileAndClose(outputPath)
 }
@@ -637,5 +1150,9 @@ func (a *App) mergePDFs(outputDir, mergeFilename string) {
 		return
 	}
 
+	if err := mergePDFPageLabels(pdfFiles, mergedPath); err != nil {
+		mergeLog(fmt.Sprintf("Page labels not copied to merged PDF: %v", err), true)
+	}
+
 	mergeLog(fmt.Sprintf("Merge complete! %d PDFs merged into: %s", len(pdfFiles), mergedPath), false)
 }
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultOCRCacheMB is the OCR cache size limit used when none is configured
+const defaultOCRCacheMB = 500
+
+// ocrCacheEntry describes one cached provider response
+type ocrCacheEntry struct {
+	Key      string    `json:"key"`
+	Provider string    `json:"provider"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"lastUsed"`
+	path     string
+}
+
+// ocrCacheDir holds provider responses keyed by input content, shared by
+// every book and run. Entries are plain files; their modification time is
+// the last time they were used.
+func (a *App) ocrCacheDir() string {
+	return filepath.Join(a.exeDir(), "cache", "ocr")
+}
+
+func (a *App) ocrCacheMaxBytes() int64 {
+	mb := a.config.CacheMaxMB
+	if mb < 1 {
+		mb = defaultOCRCacheMB
+	}
+	return int64(mb) << 20
+}
+
+func providerName(provider string) string {
+	if provider == "" {
+		return "google"
+	}
+	return provider
+}
+
+// ocrCacheKey hashes the exact file sent to the provider together with every
+// setting that changes the provider's answer. Scan mode is not part of the
+// key: the left/right split is done later, from the stored response.
+// backend names the provider behind the call when that is not the provider's
+// own endpoint, or the Tesseract install (see cacheBackend).
+func ocrCacheKey(filePath string, settings OCRSettings, backend string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	provider := providerName(settings.Provider)
+	fmt.Fprintf(h, "%s\x00%s\x00", provider, strings.Join(settings.Languages, ","))
+	if provider == "ocrspace" {
+		// The plan decides how far large images are shrunk before upload
+		fmt.Fprintf(h, "%d\x00%s\x00", settings.OcrSpaceEngine, settings.OcrSpacePlan)
+	}
+	if backend != "" {
+		// A stub server or emulator must not answer for the real provider,
+		// nor an older Tesseract for a newer one
+		fmt.Fprintf(h, "%s\x00", backend)
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheBackend names what answers the provider calls of a run for its cache
+// key: the endpoint, "" for the provider's own so that the keys of existing
+// entries stay valid, or the Tesseract binary, version and traineddata
+func (a *App) cacheBackend(settings OCRSettings) string {
+	switch providerName(settings.Provider) {
+	case "google":
+		endpoint, plaintext := a.visionEndpointFor(settings)
+		if plaintext {
+			endpoint = "plaintext:" + endpoint
+		}
+		return endpoint
+	case "ocrspace":
+		if url := a.ocrSpaceURLFor(settings); url != defaultOcrSpaceURL {
+			return url
+		}
+	case "tesseract":
+		return a.tesseractCacheID(settings)
+	}
+	return ""
+}
+
+func ocrCachePath(dir, provider, key string) string {
+	provider = providerName(provider)
+	ext := ".json"
+	if provider == "tesseract" {
+		ext = ".tsv"
+	}
+	return filepath.Join(dir, provider+"-"+key+ext)
+}
+
+// loadCachedResponse returns the cached response for key and marks it as
+// used. An empty key never hits.
+func loadCachedResponse(dir, provider, key string) ([]byte, bool) {
+	if key == "" {
+		return nil, false
+	}
+	path := ocrCachePath(dir, provider, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+// storeCachedResponse writes a response to the cache. The file is renamed
+// into place so concurrent readers never see a partial entry.
+func storeCachedResponse(dir, provider, key string, data []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), ocrCachePath(dir, provider, key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// listOCRCache returns every cache entry, most recently used first
+func listOCRCache(dir string) ([]ocrCacheEntry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []ocrCacheEntry
+	for _, e := range dirEntries {
+		name := e.Name()
+		provider, rest, ok := strings.Cut(name, "-")
+		if e.IsDir() || !ok || provider == "tmp" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, ocrCacheEntry{
+			Key:      strings.TrimSuffix(rest, filepath.Ext(rest)),
+			Provider: provider,
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+			path:     filepath.Join(dir, name),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.After(entries[j].LastUsed) })
+	return entries, nil
+}
+
+// pruneOCRCache evicts least recently used entries until the cache fits in
+// maxBytes, and every entry not used within maxAge (0 = no age limit).
+func pruneOCRCache(dir string, maxBytes int64, maxAge time.Duration) (removed int, freed int64, err error) {
+	entries, err := listOCRCache(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+		expired := maxAge > 0 && time.Since(e.LastUsed) > maxAge
+		if total <= maxBytes && !expired {
+			continue
+		}
+		if err := os.Remove(e.path); err != nil {
+			return removed, freed, err
+		}
+		total -= e.Size
+		removed++
+		freed += e.Size
+	}
+	return removed, freed, nil
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFakeTesseract writes a stand-in tesseract that prints version for
+// --version and names tessdata for --list-langs
+func writeFakeTesseract(t *testing.T, path, version, tessdata string, modTime time.Time) {
+	t.Helper()
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = --version ]; then echo 'tesseract " + version + "'; exit 0; fi\n" +
+		"echo 'List of available languages in \"" + tessdata + "/\" (1):'\necho eng\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOCRCacheKeyTesseract(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in tesseract is a shell script")
+	}
+	dir := t.TempDir()
+	tessdata := filepath.Join(dir, "tessdata")
+	if err := os.Mkdir(tessdata, 0755); err != nil {
+		t.Fatal(err)
+	}
+	traineddata := filepath.Join(tessdata, "eng.traineddata")
+	if err := os.WriteFile(traineddata, []byte("fast model"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tesseract := filepath.Join(dir, "tesseract")
+	modTime := time.Now().Add(-time.Hour)
+	writeFakeTesseract(t, tesseract, "5.3.0", tessdata, modTime)
+	page := writeTestPage(t)
+
+	a := NewApp()
+	settings := OCRSettings{Provider: "tesseract", TesseractPath: tesseract, Languages: []string{"en"}}
+	key := func() string {
+		t.Helper()
+		k, err := ocrCacheKey(page, settings, a.cacheBackend(settings))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	first := key()
+	if again := key(); again != first {
+		t.Fatal("key changed without any change to tesseract")
+	}
+	if id := a.tesseractCacheID(settings); !strings.Contains(id, "tesseract 5.3.0") {
+		t.Errorf("cache ID %q lacks the version", id)
+	}
+
+	// a swapped language pack
+	if err := os.WriteFile(traineddata, []byte("best model, larger"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	swapped := key()
+	if swapped == first {
+		t.Error("same key after the traineddata changed")
+	}
+
+	// an upgrade in place
+	writeFakeTesseract(t, tesseract, "5.4.1", tessdata, modTime.Add(time.Minute))
+	if key() == swapped {
+		t.Error("same key after tesseract was upgraded")
+	}
+
+	// another binary
+	other := filepath.Join(dir, "tesseract-other")
+	writeFakeTesseract(t, other, "5.4.1", tessdata, modTime)
+	withOther := settings
+	withOther.TesseractPath = other
+	k, err := ocrCacheKey(page, withOther, a.cacheBackend(withOther))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k == key() {
+		t.Error("same key for another tesseract binary")
+	}
+}
+
+func TestTessdataDir(t *testing.T) {
+	tests := []struct{ out, want string }{
+		{"List of available languages in \"/usr/share/tesseract-ocr/5/tessdata/\" (3):\neng\nosd\n", "/usr/share/tesseract-ocr/5/tessdata/"},
+		{"List of available languages in \"C:\\Program Files\\Tesseract-OCR/tessdata/\" (2):\r\neng\r\n", "C:\\Program Files\\Tesseract-OCR/tessdata/"},
+		{"List of available languages (2):\neng\nosd\n", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := tessdataDir([]byte(tt.out)); got != tt.want {
+			t.Errorf("tessdataDir(%q) = %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/tiff"
+)
+
+// ocrReencodeQuality is the JPEG quality used when an image has to be
+// re-encoded before it is sent to an OCR provider.
+const ocrReencodeQuality = 95
+
+// ocrProviderExts lists the file types each provider takes as-is. Anything
+// else (and every page of a multi-page TIFF) is converted first.
+var ocrProviderExts = map[string]map[string]bool{
+	"google": {
+		".jpg": true, ".jpeg": true, ".png": true, ".bmp": true, ".webp": true,
+	},
+	"ocrspace": {
+		".jpg": true, ".jpeg": true, ".png": true, ".bmp": true, ".tif": true, ".tiff": true,
+	},
+	"tesseract": {
+		".jpg": true, ".jpeg": true, ".png": true, ".bmp": true, ".tif": true, ".tiff": true,
+	},
+}
+
+func isTIFFExt(ext string) bool {
+	ext = strings.ToLower(ext)
+	return ext == ".tif" || ext == ".tiff"
+}
+
+// tiffIFDOffsets walks the IFD chain of a classic (non-Big) TIFF and returns
+// the offset of every image directory, i.e. one per page.
+func tiffIFDOffsets(data []byte) ([]uint32, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("tiff: file too short")
+	}
+	var bo binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("tiff: bad byte order")
+	}
+	if bo.Uint16(data[2:4]) != 42 {
+		return nil, fmt.Errorf("tiff: unsupported version")
+	}
+
+	var offsets []uint32
+	seen := make(map[uint32]bool)
+	for off := bo.Uint32(data[4:8]); off != 0; {
+		if seen[off] || int(off)+2 > len(data) {
+			break
+		}
+		seen[off] = true
+		offsets = append(offsets, off)
+		n := int(bo.Uint16(data[off:]))
+		next := int(off) + 2 + n*12
+		if next+4 > len(data) {
+			break
+		}
+		off = bo.Uint32(data[next:])
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("tiff: no image directory")
+	}
+	return offsets, nil
+}
+
+// ocrPageCount returns how many pages filePath holds: the number of images in
+// a TIFF, 1 for everything else.
+func ocrPageCount(filePath string) (int, error) {
+	if !isTIFFExt(filepath.Ext(filePath)) {
+		return 1, nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("read: %w", err)
+	}
+	offsets, err := tiffIFDOffsets(data)
+	if err != nil {
+		return 0, err
+	}
+	return len(offsets), nil
+}
+
+// decodeOCRPage decodes page (0-based) of filePath and turns it upright
+// according to its EXIF orientation. x/image/tiff only reads the first
+// directory, so for later TIFF pages the header is patched to point at the
+// wanted directory; all TIFF offsets are absolute, so the rest of the file
+// stays valid.
+func decodeOCRPage(filePath string, page int) (image.Image, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	if page > 0 {
+		offsets, err := tiffIFDOffsets(data)
+		if err != nil {
+			return nil, err
+		}
+		if page >= len(offsets) {
+			return nil, fmt.Errorf("tiff: page %d out of range (%d pages)", page+1, len(offsets))
+		}
+		bo := binary.ByteOrder(binary.LittleEndian)
+		if data[0] == 'M' {
+			bo = binary.BigEndian
+		}
+		bo.PutUint32(data[4:8], offsets[page])
+		img, err := tiff.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decode page %d: %w", page+1, err)
+		}
+		return img, nil
+	}
+
+	orientation := readOrientation(bytes.NewReader(data))
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return applyOrientation(img, orientation), nil
+}
+
+// encodeOCRImage encodes a page for upload. Gray and palette images (bilevel
+// scans) become PNG, which is lossless and small for them; the rest JPEG.
+func encodeOCRImage(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch img.(type) {
+	case *image.Gray, *image.Gray16, *image.Paletted:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("encode: %w", err)
+		}
+		return buf.Bytes(), ".png", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: ocrReencodeQuality}); err != nil {
+		return nil, "", fmt.Errorf("encode: %w", err)
+	}
+	return buf.Bytes(), ".jpg", nil
+}
+
+// writeOCRTempImage encodes img into a temporary file named after filePath.
+func writeOCRTempImage(filePath string, img image.Image) (string, func(), error) {
+	data, ext, err := encodeOCRImage(img)
+	if err != nil {
+		return "", nil, err
+	}
+	base := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	tmp, err := os.CreateTemp("", "book2ocr-"+base+"-*"+ext)
+	if err != nil {
+		return "", nil, fmt.Errorf("create temp: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, fmt.Errorf("write temp: %w", err)
+	}
+	return tmp.Name(), func() { os.Remove(tmp.Name()) }, nil
+}
+
+// ocrPageFile returns a path to page (0-based, of pageCount) of filePath that
+// the provider can read directly and that is upright per EXIF. The original
+// path is returned when it already qualifies; otherwise the page is decoded
+// and written to a temporary PNG or JPEG. Providers (Tesseract in particular)
+// ignore the EXIF orientation tag, hence the rotation. The cleanup func
+// removes any temporary file.
+func ocrPageFile(filePath string, page, pageCount int, provider string) (string, func(), error) {
+	if pageCount <= 1 && ocrProviderExts[provider][strings.ToLower(filepath.Ext(filePath))] {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return "", nil, fmt.Errorf("open: %w", err)
+		}
+		orientation := readOrientation(f)
+		f.Close()
+		if orientation <= 1 || orientation > 8 {
+			return filePath, func() {}, nil
+		}
+	}
+
+	img, err := decodeOCRPage(filePath, page)
+	if err != nil {
+		return "", nil, err
+	}
+	return writeOCRTempImage(filePath, img)
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -53,11 +54,12 @@ type ocrSpaceResponse struct {
 	OCRExitCode           int      `json:"OCRExitCode"`
 }
 
-// callOcrSpace sends an image to OCR.space API and returns the extracted text
-func (a *App) callOcrSpace(filePath string, settings OCRSettings) (string, error) {
+// callOcrSpace sends an image to OCR.space API and returns the raw JSON
+// response; ocrSpaceText extracts the text from it
+func (a *App) callOcrSpace(ctx context.Context, filePath string, settings OCRSettings) ([]byte, error) {
 	imgData, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("read: %w", err)
+		return nil, fmt.Errorf("read: %w", err)
 	}
 
 	// Shrink image if it exceeds the plan's file size limit
@@ -68,7 +70,7 @@ func (a *App) callOcrSpace(filePath string, settings OCRSettings) (string, error
 	if len(imgData) > maxBytes {
 		imgData, err = shrinkImageToFit(imgData, maxBytes)
 		if err != nil {
-			return "", fmt.Errorf("shrink image: %w", err)
+			return nil, fmt.Errorf("shrink image: %w", err)
 		}
 	}
 
@@ -79,10 +81,10 @@ func (a *App) callOcrSpace(filePath string, settings OCRSettings) (string, error
 	// Add image file
 	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
 	if err != nil {
-		return "", fmt.Errorf("create form: %w", err)
+		return nil, fmt.Errorf("create form: %w", err)
 	}
 	if _, err := part.Write(imgData); err != nil {
-		return "", fmt.Errorf("write form: %w", err)
+		return nil, fmt.Errorf("write form: %w", err)
 	}
 
 	// Map language code
@@ -107,17 +109,21 @@ func (a *App) callOcrSpace(filePath string, settings OCRSettings) (string, error
 	writer.Close()
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(a.ctx, "POST", "https://api.ocr.space/parse/image", &buf)
+	client, err := a.ocrSpaceClientFor(settings)
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.ocrSpaceURLFor(settings), &buf)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("apikey", settings.OcrSpaceApiKey)
 
 	// Send request
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("HTTP: %w", err)
+		return nil, fmt.Errorf("HTTP: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -127,14 +133,22 @@ func (a *App) callOcrSpace(filePath string, settings OCRSettings) (string, error
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, &httpStatusError{Code: resp.StatusCode, Body: string(body)}
 	}
 
-	// Parse response
+	// Validate the response before it is stored
+	if _, err := ocrSpaceText(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// ocrSpaceText extracts the recognized text from an OCR.space JSON response
+func ocrSpaceText(body []byte) (string, error) {
 	var result ocrSpaceResponse
 	if err := json.Unmarshal(body, &result); err != nil {
 		return "", fmt.Errorf("parse response: %w", err)
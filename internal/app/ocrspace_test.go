@@ -0,0 +1,218 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ocrSpaceUpload is what the fake OCR.space server received
+type ocrSpaceUpload struct {
+	apiKey, language, engine string
+	fileName                 string
+	file                     []byte
+}
+
+// fakeOcrSpace records each upload and answers with reply
+func fakeOcrSpace(t *testing.T, reply func(w http.ResponseWriter)) (*httptest.Server, chan ocrSpaceUpload) {
+	t.Helper()
+	uploads := make(chan ocrSpaceUpload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(16 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		u := ocrSpaceUpload{
+			apiKey:   r.Header.Get("apikey"),
+			language: r.FormValue("language"),
+			engine:   r.FormValue("OCREngine"),
+		}
+		if f, h, err := r.FormFile("file"); err == nil {
+			u.fileName = h.Filename
+			u.file, _ = io.ReadAll(f)
+			f.Close()
+		}
+		uploads <- u
+		reply(w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, uploads
+}
+
+func replyText(text string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		io.WriteString(w, `{"ParsedResults":[{"ParsedText":"`+text+`"}],"IsErroredOnProcessing":false,"OCRExitCode":1}`)
+	}
+}
+
+func TestCallOcrSpaceForm(t *testing.T) {
+	tests := []struct {
+		lang, wantLang string
+		engine         int
+		wantEngine     string
+	}{
+		{"de", "ger", 2, "2"},
+		{"zh-TW", "cht", 3, "3"},
+		{"xx", "eng", 9, "1"}, // unknown language and engine fall back
+	}
+	for _, tt := range tests {
+		srv, uploads := fakeOcrSpace(t, replyText("Hallo Welt"))
+		page := writeTestPage(t)
+		a := NewApp()
+		body, err := a.callOcrSpace(context.Background(), page, OCRSettings{
+			OcrSpaceURL:    srv.URL,
+			OcrSpaceApiKey: "k123",
+			OcrSpaceEngine: tt.engine,
+			Languages:      []string{tt.lang},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.lang, err)
+		}
+		u := <-uploads
+		if u.apiKey != "k123" || u.language != tt.wantLang || u.engine != tt.wantEngine {
+			t.Errorf("%s: apikey %q, language %q, OCREngine %q", tt.lang, u.apiKey, u.language, u.engine)
+		}
+		want, _ := os.ReadFile(page)
+		if u.fileName != "page.png" || !bytes.Equal(u.file, want) {
+			t.Errorf("%s: file part %q, %d bytes", tt.lang, u.fileName, len(u.file))
+		}
+		if text, err := ocrSpaceText(body); err != nil || text != "Hallo Welt" {
+			t.Errorf("%s: text %q, %v", tt.lang, text, err)
+		}
+	}
+}
+
+// writeNoisePage writes a PNG of random pixels, which barely compresses:
+// 700×700 comes to about 2 MB
+func writeNoisePage(t *testing.T) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 700, 700))
+	rand.New(rand.NewSource(4)).Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "noise.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCallOcrSpaceShrink(t *testing.T) {
+	page := writeNoisePage(t)
+	orig, _ := os.ReadFile(page)
+	if len(orig) <= ocrSpaceFreeMaxBytes || len(orig) > ocrSpaceProMaxBytes {
+		t.Fatalf("noise page is %d bytes, want between the plan limits", len(orig))
+	}
+
+	for _, plan := range []string{"free", "pro"} {
+		srv, uploads := fakeOcrSpace(t, replyText(""))
+		a := NewApp()
+		_, err := a.callOcrSpace(context.Background(), page, OCRSettings{OcrSpaceURL: srv.URL, OcrSpacePlan: plan})
+		if err != nil {
+			t.Fatalf("%s: %v", plan, err)
+		}
+		u := <-uploads
+		if plan == "pro" {
+			if !bytes.Equal(u.file, orig) {
+				t.Errorf("pro: upload changed, %d bytes", len(u.file))
+			}
+			continue
+		}
+		if len(u.file) > ocrSpaceFreeMaxBytes {
+			t.Errorf("free: upload is %d bytes, limit %d", len(u.file), ocrSpaceFreeMaxBytes)
+		}
+		if _, err := jpeg.DecodeConfig(bytes.NewReader(u.file)); err != nil {
+			t.Errorf("free: upload is not a JPEG: %v", err)
+		}
+	}
+}
+
+func TestCallOcrSpaceTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	a := NewApp()
+	start := time.Now()
+	_, err := a.callOcrSpace(context.Background(), writeTestPage(t), OCRSettings{OcrSpaceURL: srv.URL, OcrSpaceTimeoutSec: 1})
+	if err == nil {
+		t.Fatal("no error from a server that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("returned after %v with a 1s timeout", elapsed)
+	}
+	if class := classifyOCRError(err); class != errClassTimeout {
+		t.Errorf("class %q, want timeout: %v", class, err)
+	}
+}
+
+func TestCallOcrSpaceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   func(w http.ResponseWriter)
+		wantErr string
+		class   string
+	}{
+		{"server error", func(w http.ResponseWriter) {
+			http.Error(w, "down", http.StatusInternalServerError)
+		}, "HTTP 500", errClassServer},
+		{"forbidden", func(w http.ResponseWriter) {
+			http.Error(w, "bad key", http.StatusForbidden)
+		}, "HTTP 403", errClassAuth},
+		{"errored on processing", func(w http.ResponseWriter) {
+			io.WriteString(w, `{"IsErroredOnProcessing":true,"ErrorMessage":["E301: bad image","try again"],"OCRExitCode":3}`)
+		}, "OCR.space error: E301: bad image; try again", ""},
+		{"malformed JSON", func(w http.ResponseWriter) {
+			io.WriteString(w, `{"ParsedResults":[`)
+		}, "parse response", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, uploads := fakeOcrSpace(t, tt.reply)
+			a := NewApp()
+			_, err := a.callOcrSpace(context.Background(), writeTestPage(t), OCRSettings{OcrSpaceURL: srv.URL})
+			<-uploads
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			var httpErr *httpStatusError
+			if tt.class != "" {
+				if !errors.As(err, &httpErr) {
+					t.Errorf("err is %T, want *httpStatusError", err)
+				}
+				if class := classifyOCRError(err); class != tt.class {
+					t.Errorf("class %q, want %q", class, tt.class)
+				}
+			}
+		})
+	}
+}
+
+func TestOcrSpaceTextEmpty(t *testing.T) {
+	text, err := ocrSpaceText([]byte(`{"ParsedResults":[],"IsErroredOnProcessing":false}`))
+	if err != nil || text != "" {
+		t.Errorf("text %q, err %v", text, err)
+	}
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultOcrSpaceURL is the OCR.space endpoint when neither the job nor
+	// config.json sets one; PRO accounts get their own from OCR.space
+	defaultOcrSpaceURL = "https://api.ocr.space/parse/image"
+	// defaultOcrSpaceTimeout bounds one OCR.space request, upload to response
+	defaultOcrSpaceTimeout = 60 * time.Second
+	// ocrSpaceIdleConns is how many connections to the endpoint are kept
+	// open between pages, enough for the largest worker pool
+	ocrSpaceIdleConns = 32
+)
+
+// ocrSpaceURLFor returns the OCR.space endpoint of a run: the job's, then
+// config.json's, then the public API
+func (a *App) ocrSpaceURLFor(settings OCRSettings) string {
+	if settings.OcrSpaceURL != "" {
+		return settings.OcrSpaceURL
+	}
+	if a.config.OcrSpaceURL != "" {
+		return a.config.OcrSpaceURL
+	}
+	return defaultOcrSpaceURL
+}
+
+// ocrSpaceClientFor returns the HTTP client of a run, with the timeout and
+// proxy from the job, then config.json. Without a proxy the HTTPS_PROXY
+// environment variable applies. Clients are shared by every run with the
+// same timeout and proxy, so that queued jobs reuse their connections.
+func (a *App) ocrSpaceClientFor(settings OCRSettings) (*http.Client, error) {
+	timeout := defaultOcrSpaceTimeout
+	if settings.OcrSpaceTimeoutSec > 0 {
+		timeout = time.Duration(settings.OcrSpaceTimeoutSec) * time.Second
+	} else if a.config.OcrSpaceTimeoutSec > 0 {
+		timeout = time.Duration(a.config.OcrSpaceTimeoutSec) * time.Second
+	}
+	proxy := settings.OcrSpaceProxy
+	if proxy == "" {
+		proxy = a.config.OcrSpaceProxy
+	}
+
+	a.httpClientsMu.Lock()
+	defer a.httpClientsMu.Unlock()
+	key := strconv.Itoa(int(timeout.Seconds())) + " " + proxy
+	if c := a.httpClients[key]; c != nil {
+		return c, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = ocrSpaceIdleConns
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", proxy)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	c := &http.Client{Transport: transport, Timeout: timeout}
+	if a.httpClients == nil {
+		a.httpClients = make(map[string]*http.Client)
+	}
+	a.httpClients[key] = c
+	return c, nil
+}
@@ -0,0 +1,438 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nfnt/resize"
+)
+
+// Orientation detection works on a downscaled copy of the page; this is the
+// longest side of that copy.
+const orientAnalysisSize = 1200
+
+// Pages are only deskewed when the detected angle is at least this many
+// degrees, and the search never goes beyond orientMaxSkew.
+const (
+	orientMinSkew = 0.3
+	orientMaxSkew = 5.0
+)
+
+// Rotations below these confidences are ignored. The OSD value is tesseract's
+// "Orientation confidence"; the heuristic value is the ratio of line votes.
+const (
+	orientMinOSDConfidence       = 5.0
+	orientMinHeuristicConfidence = 2.0
+)
+
+// orientFix describes how a page must be turned to be upright.
+type orientFix struct {
+	Rotation   int     // clockwise degrees: 0, 90, 180 or 270
+	Source     string  // "osd" or "heuristic"
+	Confidence float64 // detector-specific confidence
+	Skew       float64 // degrees the text lines descend to the right
+}
+
+func (f orientFix) needed() bool {
+	return f.Rotation != 0 || math.Abs(f.Skew) >= orientMinSkew
+}
+
+var (
+	osdRotatePattern     = regexp.MustCompile(`Rotate:\s*(\d+)`)
+	osdConfidencePattern = regexp.MustCompile(`Orientation confidence:\s*([\d.]+)`)
+)
+
+// detectOrientation determines the rotation and skew of an image that has
+// already been made EXIF-upright. Tesseract OSD is used when a tesseract
+// binary is available; otherwise a line-direction heuristic runs. The
+// heuristic never rotates pages for CJK languages, where vertical text lines
+// are legitimately upright.
+func detectOrientation(ctx context.Context, img image.Image, tesseractPath string, languages []string) orientFix {
+	small := resize.Thumbnail(orientAnalysisSize, orientAnalysisSize, img, resize.Bilinear)
+	ink := newInkMask(small)
+
+	var fix orientFix
+	if tesseractPath != "" {
+		if rot, conf, err := tesseractOSD(ctx, tesseractPath, small); err == nil {
+			fix = orientFix{Rotation: rot, Source: "osd", Confidence: conf}
+			if conf < orientMinOSDConfidence {
+				fix.Rotation = 0
+			}
+		}
+	}
+	if fix.Source == "" && !hasCJKLanguage(languages) {
+		fix.Rotation, fix.Confidence = orientationHeuristic(ink)
+		fix.Source = "heuristic"
+		if fix.Confidence < orientMinHeuristicConfidence {
+			fix.Rotation = 0
+		}
+	}
+
+	if fix.Rotation != 0 {
+		ink = rotateMask(ink, fix.Rotation)
+	}
+	fix.Skew = detectSkew(ink)
+	return fix
+}
+
+// applyOrientFix returns img rotated and deskewed according to fix.
+func applyOrientFix(img image.Image, fix orientFix) image.Image {
+	switch fix.Rotation {
+	case 90:
+		img = rotate90CW(img)
+	case 180:
+		img = rotate180(img)
+	case 270:
+		img = rotate90CCW(img)
+	}
+	if math.Abs(fix.Skew) >= orientMinSkew {
+		img = deskewImage(img, fix.Skew)
+	}
+	return img
+}
+
+// tesseractOSD runs tesseract's orientation and script detection (--psm 0)
+// and returns the clockwise rotation that makes the page upright. It needs
+// osd.traineddata; without it tesseract fails and the caller falls back.
+func tesseractOSD(ctx context.Context, tesseractPath string, img image.Image) (int, float64, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return 0, 0, err
+	}
+	tmp, err := os.CreateTemp("", "book2ocr-osd-*.jpg")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(buf.Bytes())
+	tmp.Close()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, tesseractPath, tmp.Name(), "stdout", "--psm", "0")
+	cmd.WaitDelay = 5 * time.Second
+	hideCommandWindow(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("tesseract osd: %w", err)
+	}
+
+	m := osdRotatePattern.FindSubmatch(out)
+	if m == nil {
+		return 0, 0, fmt.Errorf("tesseract osd: no rotation in output")
+	}
+	rot, _ := strconv.Atoi(string(m[1]))
+	conf := 0.0
+	if c := osdConfidencePattern.FindSubmatch(out); c != nil {
+		conf, _ = strconv.ParseFloat(string(c[1]), 64)
+	}
+	return rot % 360, conf, nil
+}
+
+func hasCJKLanguage(languages []string) bool {
+	for _, l := range languages {
+		if l == "ja" || strings.HasPrefix(l, "zh") {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Heuristic detection on a binary ink mask ---
+
+// inkMask is a bilevel page: true marks a dark (ink) pixel.
+type inkMask struct {
+	w, h int
+	pix  []bool
+}
+
+func (m inkMask) at(x, y int) bool { return m.pix[y*m.w+x] }
+
+func newInkMask(img image.Image) inkMask {
+	g := toBilevel(img)
+	b := g.Bounds()
+	m := inkMask{w: b.Dx(), h: b.Dy(), pix: make([]bool, b.Dx()*b.Dy())}
+	for y := 0; y < m.h; y++ {
+		row := g.Pix[g.PixOffset(b.Min.X, b.Min.Y+y):][:m.w]
+		for x, v := range row {
+			m.pix[y*m.w+x] = v == 0
+		}
+	}
+	return m
+}
+
+// rotateMask turns the mask clockwise by a multiple of 90 degrees.
+func rotateMask(m inkMask, degrees int) inkMask {
+	for ; degrees >= 90; degrees -= 90 {
+		r := inkMask{w: m.h, h: m.w, pix: make([]bool, len(m.pix))}
+		for y := 0; y < m.h; y++ {
+			for x := 0; x < m.w; x++ {
+				r.pix[x*r.w+(m.h-1-y)] = m.at(x, y)
+			}
+		}
+		m = r
+	}
+	return m
+}
+
+// orientationHeuristic guesses the clockwise rotation needed to make the text
+// upright. Line direction (horizontal vs vertical) is the axis whose
+// skew-corrected projection is sharpest; up vs down comes from Latin-script
+// ascenders outnumbering descenders. The returned confidence is the ratio of
+// lines voting for the chosen direction to lines voting against it.
+func orientationHeuristic(m inkMask) (int, float64) {
+	skew, score := skewSearch(m)
+	if score == 0 {
+		return 0, 0
+	}
+
+	base := 0
+	m90 := rotateMask(m, 90)
+	if skew90, score90 := skewSearch(m90); score90 > score*1.2 {
+		// Lines run vertically; turn them horizontal before checking up/down
+		base, m, skew = 90, m90, skew90
+	}
+
+	// +1 smooths pages with only a handful of lines
+	asc, desc := ascenderBalance(projectRows(m, skew))
+	if asc+desc == 0 {
+		return base, 0
+	}
+	if desc > asc {
+		return (base + 180) % 360, (desc + 1) / (asc + 1)
+	}
+	return base, (asc + 1) / (desc + 1)
+}
+
+// ascenderBalance splits a horizontal projection profile into text lines and
+// counts the lines with more ink above their core (x-height) band than below
+// it (asc) and vice versa (desc).
+func ascenderBalance(rows []int) (asc, desc float64) {
+	peak := 0
+	for _, v := range rows {
+		peak = max(peak, v)
+	}
+	if peak == 0 {
+		return 0, 0
+	}
+
+	minInk := max(1, peak/50)
+	for y := 0; y < len(rows); {
+		if rows[y] < minInk {
+			y++
+			continue
+		}
+		start := y
+		linePeak := 0
+		for y < len(rows) && rows[y] >= minInk {
+			linePeak = max(linePeak, rows[y])
+			y++
+		}
+		end := y
+		if end-start < 4 {
+			continue
+		}
+
+		// Core band: rows holding at least a third of the line's peak ink
+		coreTop, coreBottom := -1, -1
+		for i := start; i < end; i++ {
+			if rows[i]*3 >= linePeak {
+				if coreTop < 0 {
+					coreTop = i
+				}
+				coreBottom = i
+			}
+		}
+		var above, below int
+		for i := start; i < coreTop; i++ {
+			above += rows[i]
+		}
+		for i := coreBottom + 1; i < end; i++ {
+			below += rows[i]
+		}
+		switch {
+		case above > below:
+			asc++
+		case below > above:
+			desc++
+		}
+	}
+	return asc, desc
+}
+
+// projectRows counts ink pixels along lines tilted by deg degrees (positive
+// = descending to the right), i.e. the row profile of the deskewed mask. The
+// profile is padded so that every tilted line fits.
+func projectRows(m inkMask, deg float64) []int {
+	sin, cos := math.Sincos(deg * math.Pi / 180)
+	pad := int(math.Abs(sin)*float64(m.w)) + 1
+	rows := make([]int, m.h+2*pad)
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			if m.at(x, y) {
+				rows[int(float64(y)*cos-float64(x)*sin)+pad]++
+			}
+		}
+	}
+	return rows
+}
+
+// detectSkew returns the angle, within ±orientMaxSkew degrees, at which the
+// text lines of m run. A positive result means lines descend to the right.
+func detectSkew(m inkMask) float64 {
+	skew, _ := skewSearch(m)
+	return skew
+}
+
+// skewSearch finds the tilt whose row projection is sharpest (largest sum of
+// squared bin counts) and returns it along with that score. The score is 0
+// when the mask holds too little ink to judge.
+func skewSearch(m inkMask) (float64, float64) {
+	var xs, ys []float64
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			if m.at(x, y) {
+				xs = append(xs, float64(x))
+				ys = append(ys, float64(y))
+			}
+		}
+	}
+	if len(xs) < 100 {
+		return 0, 0
+	}
+
+	diag := int(math.Hypot(float64(m.w), float64(m.h))) + 2
+	bins := make([]float64, 2*diag)
+	score := func(deg float64) float64 {
+		sin, cos := math.Sincos(deg * math.Pi / 180)
+		clear(bins)
+		for i := range xs {
+			bins[int(ys[i]*cos-xs[i]*sin)+diag]++
+		}
+		var s float64
+		for _, v := range bins {
+			s += v * v
+		}
+		return s
+	}
+
+	best, bestScore := 0.0, score(0)
+	search := func(from, to, step float64) {
+		for deg := from; deg <= to+1e-9; deg += step {
+			if s := score(deg); s > bestScore {
+				best, bestScore = deg, s
+			}
+		}
+	}
+	search(-orientMaxSkew, orientMaxSkew, 0.25)
+	search(best-0.25, best+0.25, 0.05)
+	return math.Round(best*100) / 100, bestScore
+}
+
+// deskewImage rotates img counter-clockwise by skew degrees around its center
+// using bilinear sampling, so lines that descended to the right become
+// horizontal. Uncovered corners are filled with white.
+func deskewImage(img image.Image, skew float64) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	src, ok := img.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		src = image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sin, cos := math.Sincos(skew * math.Pi / 180)
+	cx, cy := float64(w-1)/2, float64(h-1)/2
+	white := color.RGBA{255, 255, 255, 255}
+
+	for y := 0; y < h; y++ {
+		dy := float64(y) - cy
+		for x := 0; x < w; x++ {
+			dx := float64(x) - cx
+			sx := cx + dx*cos - dy*sin
+			sy := cy + dx*sin + dy*cos
+
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			if x0 < 0 || y0 < 0 || x0+1 >= w || y0+1 >= h {
+				dst.SetRGBA(x, y, white)
+				continue
+			}
+			fx, fy := sx-float64(x0), sy-float64(y0)
+			i00 := src.PixOffset(x0, y0)
+			i10 := i00 + 4
+			i01 := i00 + src.Stride
+			i11 := i01 + 4
+			o := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				top := float64(src.Pix[i00+c])*(1-fx) + float64(src.Pix[i10+c])*fx
+				bot := float64(src.Pix[i01+c])*(1-fx) + float64(src.Pix[i11+c])*fx
+				dst.Pix[o+c] = uint8(top*(1-fy) + bot*fy + 0.5)
+			}
+		}
+	}
+	return dst
+}
+
+// orientedImageFile detects the page orientation of filePath, which must
+// already be EXIF-upright (see ocrPageFile), and, when a correction is
+// needed, writes the corrected page to a temporary file. It returns the path
+// to OCR (filePath when nothing changed), the applied fix, and a cleanup func.
+func orientedImageFile(ctx context.Context, filePath, tesseractPath string, languages []string) (string, orientFix, func(), error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", orientFix{}, nil, fmt.Errorf("open: %w", err)
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return "", orientFix{}, nil, fmt.Errorf("decode: %w", err)
+	}
+
+	fix := detectOrientation(ctx, img, tesseractPath, languages)
+	if !fix.needed() {
+		return filePath, fix, func() {}, nil
+	}
+
+	path, cleanup, err := writeOCRTempImage(filePath, applyOrientFix(img, fix))
+	if err != nil {
+		return "", fix, nil, err
+	}
+	return path, fix, cleanup, nil
+}
+
+// orientSummary formats the orientation part of a page's log line, e.g.
+// " (rotated 180°, deskewed 1.25°)". Empty when nothing was corrected.
+func orientSummary(r PageResult) string {
+	var parts []string
+	if r.Page > 0 && (r.Rotation != 0 || r.Skew != 0) {
+		parts = append(parts, fmt.Sprintf("page %d", r.Page))
+	}
+	if r.Rotation != 0 {
+		parts = append(parts, fmt.Sprintf("rotated %d° [%s]", r.Rotation, r.RotationSource))
+	}
+	if r.Skew != 0 {
+		parts = append(parts, fmt.Sprintf("deskewed %.2f°", r.Skew))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,325 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// defaultProviderLimits are the request rates and the concurrency ceiling per
+// provider key (see limitKey). Google Vision's default quota is 1800
+// requests per minute; the OCR.space free plan is throttled per IP, so its
+// defaults stay well below what it answers with a 429.
+func defaultProviderLimits() map[string]ProviderLimit {
+	return map[string]ProviderLimit{
+		"google":        {PerMinute: 1800, MaxConcurrency: 32},
+		"ocrspace-free": {PerMinute: 20, PerHour: 180, MaxConcurrency: 2},
+		"ocrspace-pro":  {PerSecond: 10, MaxConcurrency: 10},
+		"tesseract":     {MaxConcurrency: runtime.NumCPU()},
+	}
+}
+
+// throttleCooldown keeps a burst of 429s, answered to requests sent at the
+// same limit, from halving the concurrency more than once
+const throttleCooldown = 5 * time.Second
+
+// limitKey names the provider a job's limits apply to; the OCR.space plans
+// have separate limits
+func limitKey(settings OCRSettings) string {
+	if settings.Provider == "ocrspace" {
+		if settings.OcrSpacePlan == "pro" {
+			return "ocrspace-pro"
+		}
+		return "ocrspace-free"
+	}
+	if settings.Provider == "" {
+		return "google"
+	}
+	return settings.Provider
+}
+
+// providerLimitFor takes each value from the job settings, then
+// config.json, then the defaults. Values below zero turn a limit off.
+func (a *App) providerLimitFor(settings OCRSettings) ProviderLimit {
+	key := limitKey(settings)
+	def := defaultProviderLimits()[key]
+	cfg := a.config.ProviderLimits[key]
+	pick := func(job, cfg, def int) int {
+		v := def
+		if cfg != 0 {
+			v = cfg
+		}
+		if job != 0 {
+			v = job
+		}
+		return max(v, 0)
+	}
+	return ProviderLimit{
+		PerSecond:      pick(settings.RatePerSecond, cfg.PerSecond, def.PerSecond),
+		PerMinute:      pick(settings.RatePerMinute, cfg.PerMinute, def.PerMinute),
+		PerHour:        pick(settings.RatePerHour, cfg.PerHour, def.PerHour),
+		MaxConcurrency: pick(settings.MaxConcurrency, cfg.MaxConcurrency, def.MaxConcurrency),
+	}
+}
+
+// rateLimiterFor returns the limiter shared by every job of the settings'
+// provider, so that queued jobs and hourly windows add up across runs
+func (a *App) rateLimiterFor(settings OCRSettings) *rateLimiter {
+	limit := a.providerLimitFor(settings)
+	a.limitersMu.Lock()
+	defer a.limitersMu.Unlock()
+	if a.limiters == nil {
+		a.limiters = make(map[string]*rateLimiter)
+	}
+	key := limitKey(settings)
+	l := a.limiters[key]
+	if l == nil {
+		l = &rateLimiter{}
+		a.limiters[key] = l
+	}
+	l.setLimit(limit)
+	return l
+}
+
+// rateWindow allows n requests in any period of length per
+type rateWindow struct {
+	n   int
+	per time.Duration
+}
+
+// rateLimiter holds requests back to a number per second, minute and hour,
+// counted over sliding windows. Thread-safe.
+type rateLimiter struct {
+	mu      sync.Mutex
+	windows []rateWindow
+	sent    []time.Time // start of every request within the longest window
+}
+
+func (l *rateLimiter) setLimit(limit ProviderLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.windows = l.windows[:0]
+	for _, w := range []rateWindow{
+		{limit.PerSecond, time.Second},
+		{limit.PerMinute, time.Minute},
+		{limit.PerHour, time.Hour},
+	} {
+		if w.n > 0 {
+			l.windows = append(l.windows, w)
+		}
+	}
+}
+
+// wait blocks until a request may be sent without exceeding any window, and
+// counts it as sent
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		d := l.reserve(time.Now())
+		l.mu.Unlock()
+		if d <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve records a request at now and returns 0, or returns how long to
+// wait until one of the full windows has room
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	var longest time.Duration
+	for _, w := range l.windows {
+		longest = max(longest, w.per)
+	}
+	drop := 0
+	for drop < len(l.sent) && now.Sub(l.sent[drop]) >= longest {
+		drop++
+	}
+	l.sent = l.sent[drop:]
+
+	var wait time.Duration
+	for _, w := range l.windows {
+		if len(l.sent) < w.n {
+			continue
+		}
+		// The n-th latest request has to leave the window first
+		if d := l.sent[len(l.sent)-w.n].Add(w.per).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait == 0 {
+		l.sent = append(l.sent, now)
+	}
+	return wait
+}
+
+// concurrencyController is the worker pool of one OCR run with a limit that
+// adapts: it is halved when the provider throttles (HTTP 429, gRPC
+// ResourceExhausted) and raised by one after as many successful requests as
+// the current limit, up to max. Thread-safe.
+type concurrencyController struct {
+	mu           sync.Mutex
+	limit, max   int
+	active       int
+	successes    int
+	lastThrottle time.Time
+	changed      chan struct{} // closed and replaced when a slot may be free
+	onChange     func(limit int, reason string)
+}
+
+// newConcurrencyController starts at start workers; a ceiling of 0 keeps the
+// limit at start
+func newConcurrencyController(start, ceiling int, onChange func(limit int, reason string)) *concurrencyController {
+	start = max(start, 1)
+	if ceiling <= 0 {
+		ceiling = start
+	}
+	return &concurrencyController{
+		limit:    min(start, ceiling),
+		max:      ceiling,
+		changed:  make(chan struct{}),
+		onChange: onChange,
+	}
+}
+
+// broadcast wakes every acquire; the caller holds mu
+func (c *concurrencyController) broadcast() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// acquire waits for a free slot under the current limit
+func (c *concurrencyController) acquire(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		if c.active < c.limit {
+			c.active++
+			c.mu.Unlock()
+			return nil
+		}
+		ch := c.changed
+		c.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// busy returns the number of workers holding a slot
+func (c *concurrencyController) busy() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active
+}
+
+func (c *concurrencyController) release() {
+	c.mu.Lock()
+	c.active--
+	c.broadcast()
+	c.mu.Unlock()
+}
+
+// success counts a request the provider answered
+func (c *concurrencyController) success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limit >= c.max {
+		return
+	}
+	c.successes++
+	if c.successes < c.limit {
+		return
+	}
+	c.successes = 0
+	c.limit++
+	c.broadcast()
+	if c.onChange != nil {
+		c.onChange(c.limit, "requests succeed")
+	}
+}
+
+// throttle halves the limit after the provider refused a request for its
+// rate; files in flight finish, the pool shrinks as they do
+func (c *concurrencyController) throttle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.successes = 0
+	if c.limit <= 1 || time.Since(c.lastThrottle) < throttleCooldown {
+		return
+	}
+	c.lastThrottle = time.Now()
+	c.limit = max(c.limit/2, 1)
+	if c.onChange != nil {
+		c.onChange(c.limit, "provider is throttling")
+	}
+}
+
+// pacing is what ocrOnePage needs to pace and batch provider calls, keep
+// them within budget and escalate weak pages; runOCRPipeline puts it on the
+// context
+type pacing struct {
+	workers    *concurrencyController
+	quota      quotaWatch
+	escalation *escalation    // nil = off
+	vision     *visionBatcher // nil = one image per Vision call
+}
+
+type pacingKey struct{}
+
+func withPacing(ctx context.Context, p *pacing) context.Context {
+	return context.WithValue(ctx, pacingKey{}, p)
+}
+
+func pacingFrom(ctx context.Context) *pacing {
+	p, _ := ctx.Value(pacingKey{}).(*pacing)
+	return p
+}
+
+// paced runs a provider call after the provider's rate limiter lets it
+// through and tells the controller how it went. Without pacing on ctx it
+// just calls.
+func (a *App) paced(ctx context.Context, settings OCRSettings, call func() ([]byte, error)) func() ([]byte, error) {
+	p := pacingFrom(ctx)
+	if p == nil {
+		return call
+	}
+	limiter := a.rateLimiterFor(settings)
+	return func() ([]byte, error) {
+		if err := limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		data, err := call()
+		switch {
+		case err == nil:
+			p.workers.success()
+		case classifyOCRError(err) == errClassQuota:
+			p.workers.throttle()
+		}
+		return data, err
+	}
+}
+
+// describeLimit formats the rates and ceiling for the run's log
+func describeLimit(l ProviderLimit) string {
+	s := ""
+	for _, r := range []struct {
+		n    int
+		unit string
+	}{{l.PerSecond, "s"}, {l.PerMinute, "min"}, {l.PerHour, "h"}} {
+		if r.n > 0 {
+			s += fmt.Sprintf("%d/%s, ", r.n, r.unit)
+		}
+	}
+	if s == "" {
+		s = "no rate limit, "
+	}
+	return s + fmt.Sprintf("max concurrency %d", l.MaxConcurrency)
+}
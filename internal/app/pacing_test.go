@@ -0,0 +1,142 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := &rateLimiter{}
+	l.setLimit(ProviderLimit{PerSecond: 2, PerMinute: 5})
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := time.Millisecond
+	steps := []struct {
+		at   time.Duration // since start
+		wait time.Duration // 0 = sent
+	}{
+		{0, 0},
+		{100 * ms, 0},
+		{200 * ms, 800 * ms}, // 2 in the last second: wait for the one at 0
+		{1000 * ms, 0},       // the one at 0 has left the second
+		{1050 * ms, 50 * ms}, // the one at 100ms has not
+		{1100 * ms, 0},
+		{2500 * ms, 0},                // fifth in the minute
+		{3000 * ms, 57 * time.Second}, // sixth waits for the one at 0
+		{60 * time.Second, 0},         // dropped from every window
+		{60*time.Second + 50*ms, 50 * ms},
+	}
+	for _, s := range steps {
+		if got := l.reserve(start.Add(s.at)); got != s.wait {
+			t.Errorf("at %v: wait %v, want %v", s.at, got, s.wait)
+		}
+	}
+	// only requests within the longest window are kept
+	if len(l.sent) != 5 {
+		t.Errorf("%d requests kept, want 5", len(l.sent))
+	}
+}
+
+func TestRateLimiterHour(t *testing.T) {
+	l := &rateLimiter{}
+	l.setLimit(ProviderLimit{PerMinute: 2, PerHour: 3})
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, at := range []time.Duration{0, time.Second, 2 * time.Minute} {
+		if got := l.reserve(start.Add(at)); got != 0 {
+			t.Fatalf("request %d: wait %v", i, got)
+		}
+	}
+	// the minute has room, the hour waits for the first request
+	if got := l.reserve(start.Add(3 * time.Minute)); got != 57*time.Minute {
+		t.Errorf("wait %v, want 57m", got)
+	}
+}
+
+func TestRateLimiterOff(t *testing.T) {
+	l := &rateLimiter{}
+	l.setLimit(ProviderLimit{PerSecond: 0, PerMinute: -1})
+	now := time.Now()
+	for i := range 100 {
+		if got := l.reserve(now); got != 0 {
+			t.Fatalf("request %d: wait %v without limits", i, got)
+		}
+	}
+}
+
+func TestConcurrencyController(t *testing.T) {
+	var changes []int
+	c := newConcurrencyController(8, 10, func(limit int, reason string) {
+		changes = append(changes, limit)
+	})
+	expect := func(limit int) {
+		t.Helper()
+		if c.limit != limit {
+			t.Fatalf("limit %d, want %d", c.limit, limit)
+		}
+	}
+	endCooldown := func() { c.lastThrottle = time.Now().Add(-throttleCooldown) }
+
+	c.throttle()
+	expect(4)
+	c.throttle() // a burst of 429s halves once
+	expect(4)
+	endCooldown()
+	c.throttle()
+	expect(2)
+
+	// one more worker after as many successes as the limit
+	c.success()
+	expect(2)
+	c.success()
+	expect(3)
+
+	// a throttle in the cooldown still starts the count over
+	c.success()
+	c.success()
+	c.throttle()
+	expect(3)
+	c.success()
+	c.success()
+	expect(3)
+	c.success()
+	expect(4)
+
+	for range 4 + 5 + 6 + 7 + 8 + 9 {
+		c.success()
+	}
+	expect(10)
+	for range 20 {
+		c.success() // never above the ceiling
+	}
+	expect(10)
+
+	// never below one worker
+	for range 6 {
+		endCooldown()
+		c.throttle()
+	}
+	expect(1)
+
+	want := []int{4, 2, 3, 4, 5, 6, 7, 8, 9, 10, 5, 2, 1}
+	if len(changes) != len(want) {
+		t.Fatalf("changes %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestConcurrencyControllerStart(t *testing.T) {
+	// a start above the ceiling is capped; no ceiling keeps the start
+	if c := newConcurrencyController(8, 2, nil); c.limit != 2 || c.max != 2 {
+		t.Errorf("limit %d, max %d, want 2, 2", c.limit, c.max)
+	}
+	if c := newConcurrencyController(5, 0, nil); c.limit != 5 || c.max != 5 {
+		t.Errorf("limit %d, max %d, want 5, 5", c.limit, c.max)
+	}
+	if c := newConcurrencyController(0, 0, nil); c.limit != 1 {
+		t.Errorf("limit %d, want 1", c.limit)
+	}
+}
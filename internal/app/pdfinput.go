@@ -0,0 +1,249 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vision "cloud.google.com/go/vision/v2/apiv1"
+	"github.com/go-pdf/fpdf"
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// ocrPDFSuffix is appended to the name of a searchable copy of an input PDF,
+// so the copy never overwrites its source and is not picked up by merge.
+const ocrPDFSuffix = "-ocr"
+
+func isPDFInput(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".pdf")
+}
+
+// ocrOutputName returns the PDF file name written for an OCR input file.
+func ocrOutputName(baseName string) string {
+	stem := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	if isPDFInput(baseName) {
+		return stem + ocrPDFSuffix + ".pdf"
+	}
+	return stem + ".pdf"
+}
+
+// processOnePDF OCRs an image-only PDF: the largest image on each page is
+// extracted and sent to the provider, and the recognized text is stamped onto
+// the original pages as an invisible layer. Everything else in the source
+// (page labels, outlines, metadata) is kept because the source file itself
+// is what gets written out.
+func (a *App) processOnePDF(ctx context.Context, client *vision.ImageAnnotatorClient, filePath, outputPath string, settings OCRSettings, fontPath string) ([]PageResult, error) {
+	baseName := filepath.Base(filePath)
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.EXTRACTIMAGES
+	pdfCtx, err := pdfcpuapi.ReadValidateAndOptimize(f, conf)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read pdf: %w", err)
+	}
+
+	boundaries, err := pdfCtx.PageBoundaries(nil)
+	if err != nil {
+		return nil, fmt.Errorf("read pdf: %w", err)
+	}
+	dims, err := pdfCtx.PageDims()
+	if err != nil {
+		return nil, fmt.Errorf("read pdf: %w", err)
+	}
+
+	// Page coordinates of the text layer match the extracted image, so the
+	// image must not be rotated or deskewed independently of the page.
+	// Every page is one column of text: the left/right split does not apply.
+	pageSettings := settings
+	pageSettings.AutoOrient = false
+	pageSettings.ScanMode = "single"
+
+	scans := make([]scanText, pdfCtx.PageCount)
+	results := make([]PageResult, 0, pdfCtx.PageCount)
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		path, cleanup, err := pdfPageImageFile(pdfCtx, pageNr, baseName, boundaries[pageNr-1].Rot)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pageNr, err)
+		}
+		if path == "" {
+			// No image on this page: nothing to recognize
+			continue
+		}
+
+		result := PageResult{File: baseName, Page: pageNr, Provider: settings.Provider}
+		scan, err := a.ocrOnePage(ctx, client, path, 0, 1, pageSettings, &result)
+		cleanup()
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pageNr, err)
+		}
+		scans[pageNr-1] = scan
+		results = append(results, result)
+	}
+
+	if err := stampTextLayer(filePath, outputPath, dims, scans, fontPath); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// stampTextLayer writes inFile to outFile (or back to inFile when outFile is
+// empty) with the invisible text of scans stamped onto its pages, one scan
+// per page. dims are the page sizes of inFile in points.
+func stampTextLayer(inFile, outFile string, dims []types.Dim, scans []scanText, fontPath string) error {
+	layer, err := generateTextLayerPDF(dims, scans, fontPath)
+	if err != nil {
+		return fmt.Errorf("text layer: %w", err)
+	}
+
+	// Multi-stamp: page n of the layer goes onto page n of the source
+	wm, err := pdfcpuapi.PDFMultiWatermarkForReadSeeker(bytes.NewReader(layer), 1, 1,
+		"scalefactor:1 abs, position:bl, rotation:0", true, false, types.POINTS)
+	if err != nil {
+		return fmt.Errorf("text layer: %w", err)
+	}
+	if err := pdfcpuapi.AddWatermarksFile(inFile, outFile, nil, wm, nil); err != nil {
+		if outFile != "" {
+			os.Remove(outFile)
+		}
+		return fmt.Errorf("write pdf: %w", err)
+	}
+	return nil
+}
+
+// pdfPageImageFile extracts the largest image of a PDF page (the scan) to a
+// temporary file, rotated by the page's /Rotate so it matches what a viewer
+// shows. Returns an empty path when the page has no image.
+func pdfPageImageFile(pdfCtx *model.Context, pageNr int, baseName string, rotate int) (string, func(), error) {
+	images, err := pdfcpu.ExtractPageImages(pdfCtx, pageNr, false)
+	if err != nil {
+		return "", nil, fmt.Errorf("extract image: %w", err)
+	}
+
+	var best []byte
+	bestType := ""
+	bestPixels := 0
+	for _, pi := range images {
+		if pi.Thumb {
+			continue
+		}
+		data, err := io.ReadAll(pi)
+		if err != nil {
+			return "", nil, fmt.Errorf("extract image: %w", err)
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			// Formats we cannot decode (e.g. JPEG 2000) are skipped
+			continue
+		}
+		if px := cfg.Width * cfg.Height; px > bestPixels {
+			best, bestType, bestPixels = data, pi.FileType, px
+		}
+	}
+	if best == nil {
+		return "", func() {}, nil
+	}
+
+	stem := fmt.Sprintf("%s-p%03d", strings.TrimSuffix(baseName, filepath.Ext(baseName)), pageNr)
+	rotate = (rotate%360 + 360) % 360
+	if rotate == 0 {
+		// Hand the stream over unchanged; ocrPageFile converts it if the
+		// provider needs another format
+		tmp, err := os.CreateTemp("", "book2ocr-"+stem+"-*."+bestType)
+		if err != nil {
+			return "", nil, fmt.Errorf("create temp: %w", err)
+		}
+		_, err = tmp.Write(best)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return "", nil, fmt.Errorf("write temp: %w", err)
+		}
+		return tmp.Name(), func() { os.Remove(tmp.Name()) }, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(best))
+	if err != nil {
+		return "", nil, fmt.Errorf("decode image: %w", err)
+	}
+	switch rotate {
+	case 90:
+		img = rotate90CW(img)
+	case 180:
+		img = rotate180(img)
+	case 270:
+		img = rotate90CCW(img)
+	}
+	return writeOCRTempImage(stem+".png", img)
+}
+
+// generateTextLayerPDF builds a PDF with one page per source page, sized in
+// points like the source, holding only invisible (render mode 3) text.
+// Blocks with coordinates are placed over their area of the scan; text
+// without coordinates is laid out from the top of the page.
+func generateTextLayerPDF(dims []types.Dim, scans []scanText, fontPath string) ([]byte, error) {
+	pdf := fpdf.New("P", "pt", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	fontName, tr := setupPDFFont(pdf, fontPath)
+
+	for i, d := range dims {
+		pdf.AddPageFormat("P", fpdf.SizeType{Wd: d.Width, Ht: d.Height})
+		pdf.SetTextRenderingMode(3)
+		scan := scans[i]
+
+		if len(scan.Blocks) == 0 {
+			if scan.Left != "" {
+				pdf.SetFont(fontName, "", 10)
+				pdf.SetXY(d.Width*0.05, d.Height*0.05)
+				pdf.MultiCell(d.Width*0.9, 12, tr(scan.Left), "", "L", false)
+			}
+			continue
+		}
+
+		for _, b := range scan.Blocks {
+			x, y := b.X0*d.Width, b.Y0*d.Height
+			w, h := (b.X1-b.X0)*d.Width, (b.Y1-b.Y0)*d.Height
+			lines := strings.Split(b.Text, "\n")
+			lineH := h / float64(len(lines))
+			if w <= 0 || lineH <= 0 {
+				continue
+			}
+			for n, line := range lines {
+				line = tr(line)
+				size := lineH * 0.9
+				pdf.SetFont(fontName, "", size)
+				if sw := pdf.GetStringWidth(line); sw > w {
+					size *= w / sw
+					pdf.SetFont(fontName, "", size)
+				}
+				// Baseline sits about a fifth of the line height above its bottom
+				pdf.Text(x, y+float64(n+1)*lineH-lineH*0.2, line)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,321 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Job queue statuses
+const (
+	jobPending   = "pending"
+	jobRunning   = "running"
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+func (a *App) queuePath() string {
+	return filepath.Join(a.exeDir(), "queue.json")
+}
+
+// errQueueUnchanged is returned by an updateQueue change that leaves the
+// queue as it is, so that it is not written
+var errQueueUnchanged = errors.New("queue unchanged")
+
+// loadQueue reads the job queue
+func (a *App) loadQueue() {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	a.queue = nil
+	a.reloadQueue()
+}
+
+// reloadQueue replaces the in-memory queue with queue.json, which another
+// process (the GUI, a `queue` command) may have changed. A file that cannot
+// be read keeps the queue as it is. A job still marked running by a process
+// that is gone was interrupted; it goes back to pending and its session
+// skips the files it already finished. The caller holds queueMu.
+func (a *App) reloadQueue() {
+	data, err := os.ReadFile(a.queuePath())
+	if errors.Is(err, os.ErrNotExist) {
+		a.queue = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	var jobs []*QueuedJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return
+	}
+	for _, job := range jobs {
+		if job.Status == jobRunning && job.Owner != os.Getpid() && (job.Owner == 0 || !processAlive(job.Owner)) {
+			job.Status = jobPending
+			job.Owner = 0
+		}
+	}
+	a.queue = jobs
+}
+
+// updateQueue applies change to the queue as queue.json has it and writes
+// it back, holding a lock that every process sharing the file takes, so
+// that jobs one adds are not lost to another's write. The caller holds
+// queueMu; change sees a fresh a.queue and must look jobs up in it.
+func (a *App) updateQueue(change func() error) error {
+	unlock, err := lockFile(a.queuePath() + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock queue: %w", err)
+	}
+	defer unlock()
+	a.reloadQueue()
+	if err := change(); errors.Is(err, errQueueUnchanged) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return a.saveQueue()
+}
+
+// saveQueue writes the queue through a temporary file, so that a reader
+// never sees it half written; the caller holds queueMu and the file lock
+func (a *App) saveQueue() error {
+	data, err := json.MarshalIndent(a.queue, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := a.queuePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, a.queuePath()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (a *App) findQueuedJob(id string) (int, *QueuedJob) {
+	for i, job := range a.queue {
+		if job.ID == id {
+			return i, job
+		}
+	}
+	return -1, nil
+}
+
+func newJobID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// EnqueueOCR adds an OCR job with its own settings to the end of the queue
+func (a *App) EnqueueOCR(settings OCRSettings) (QueuedJob, error) {
+	if settings.ImageDir == "" || settings.OutputDir == "" {
+		return QueuedJob{}, errors.New("image and output folder are required")
+	}
+	if len(settings.SelectedFiles) == 0 {
+		return QueuedJob{}, errors.New("no files selected")
+	}
+	job := &QueuedJob{
+		ID:       newJobID(),
+		Settings: settings,
+		Status:   jobPending,
+		AddedAt:  time.Now().Format(time.RFC3339),
+	}
+
+	a.queueMu.Lock()
+	err := a.updateQueue(func() error {
+		a.queue = append(a.queue, job)
+		return nil
+	})
+	a.queueMu.Unlock()
+	a.emitQueueChanged()
+	return *job, err
+}
+
+// GetQueue returns every queued job in run order, finished jobs included,
+// with the changes of other processes
+func (a *App) GetQueue() []QueuedJob {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	a.reloadQueue()
+	list := make([]QueuedJob, len(a.queue))
+	for i, job := range a.queue {
+		list[i] = *job
+	}
+	return list
+}
+
+// MoveQueuedJob moves a job to position index (0-based) in the queue
+func (a *App) MoveQueuedJob(id string, index int) error {
+	a.queueMu.Lock()
+	err := a.updateQueue(func() error {
+		i, job := a.findQueuedJob(id)
+		if job == nil {
+			return fmt.Errorf("job not found: %s", id)
+		}
+		index = max(0, min(index, len(a.queue)-1))
+		a.queue = append(a.queue[:i], a.queue[i+1:]...)
+		a.queue = append(a.queue[:index], append([]*QueuedJob{job}, a.queue[index:]...)...)
+		return nil
+	})
+	a.queueMu.Unlock()
+	a.emitQueueChanged()
+	return err
+}
+
+// CancelQueuedJob cancels a pending job, or stops the running one; the queue
+// then goes on with the next job
+func (a *App) CancelQueuedJob(id string) error {
+	a.queueMu.Lock()
+	err := a.updateQueue(func() error {
+		_, job := a.findQueuedJob(id)
+		switch {
+		case job == nil:
+			return fmt.Errorf("job not found: %s", id)
+		case job.Status == jobRunning && job.Owner != os.Getpid():
+			return fmt.Errorf("job %s is running in another process", id)
+		case job.Status == jobRunning:
+			if a.cancelJob != nil {
+				a.cancelJob()
+			}
+			return errQueueUnchanged
+		case job.Status == jobPending:
+			job.Status = jobCancelled
+			job.FinishedAt = time.Now().Format(time.RFC3339)
+		default:
+			return fmt.Errorf("job %s is already %s", id, job.Status)
+		}
+		return nil
+	})
+	a.queueMu.Unlock()
+	a.emitQueueChanged()
+	return err
+}
+
+// ClearFinishedJobs removes done, failed and cancelled jobs from the queue
+func (a *App) ClearFinishedJobs() error {
+	a.queueMu.Lock()
+	err := a.updateQueue(func() error {
+		var kept []*QueuedJob
+		for _, job := range a.queue {
+			if job.Status == jobPending || job.Status == jobRunning {
+				kept = append(kept, job)
+			}
+		}
+		a.queue = kept
+		return nil
+	})
+	a.queueMu.Unlock()
+	a.emitQueueChanged()
+	return err
+}
+
+// StartQueue runs the pending jobs one after another in the background.
+// StopOCR stops the queue; the interrupted job stays pending.
+func (a *App) StartQueue() string {
+	return a.startOCRRun(func(ctx context.Context) { a.runQueue(ctx, nil) })
+}
+
+// runQueue runs pending jobs in queue order until none is left or ctx is
+// cancelled. Jobs added or moved while it runs are picked up. run, if set,
+// replaces runOCRPipeline (the CLI wraps it to print per-job events).
+func (a *App) runQueue(ctx context.Context, run func(ctx context.Context, settings OCRSettings) error) {
+	if run == nil {
+		run = a.runOCRPipeline
+	}
+	for ctx.Err() == nil {
+		// A pause between jobs holds back the next one
+		if a.waitWhilePaused(ctx) != nil {
+			return
+		}
+		job, jobCtx := a.startNextJob(ctx)
+		if job == nil {
+			return
+		}
+		a.emitOCRLog("", fmt.Sprintf("Queue: starting job %s (%s)", job.ID, job.Settings.ImageDir), 0, 0, false)
+		err := run(jobCtx, job.Settings)
+		a.finishJob(job.ID, ctx, jobCtx, err)
+	}
+}
+
+// startNextJob marks the first pending job as running and returns a copy of
+// it with a context that CancelQueuedJob cancels. Returns nil when no job is
+// pending.
+func (a *App) startNextJob(ctx context.Context) (*QueuedJob, context.Context) {
+	a.queueMu.Lock()
+	defer a.emitQueueChanged()
+	defer a.queueMu.Unlock()
+	var next *QueuedJob
+	a.updateQueue(func() error {
+		for _, job := range a.queue {
+			if job.Status == jobPending {
+				job.Status = jobRunning
+				job.Owner = os.Getpid()
+				job.Error = ""
+				snapshot := *job
+				next = &snapshot
+				return nil
+			}
+		}
+		return errQueueUnchanged
+	})
+	if next == nil {
+		return nil, nil
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	a.cancelJob = cancel
+	return next, jobCtx
+}
+
+// finishJob records how a job ended. A job interrupted by stopping the whole
+// queue goes back to pending, to resume on the next run.
+func (a *App) finishJob(id string, ctx, jobCtx context.Context, err error) {
+	a.queueMu.Lock()
+	defer a.emitQueueChanged()
+	defer a.queueMu.Unlock()
+	a.updateQueue(func() error {
+		_, job := a.findQueuedJob(id)
+		if job == nil {
+			return errQueueUnchanged
+		}
+		job.Owner = 0
+		switch {
+		case ctx.Err() != nil:
+			job.Status = jobPending
+		case jobCtx.Err() != nil:
+			job.Status = jobCancelled
+		case err != nil:
+			job.Status = jobFailed
+			job.Error = err.Error()
+		default:
+			job.Status = jobDone
+		}
+		if job.Status != jobPending {
+			job.FinishedAt = time.Now().Format(time.RFC3339)
+		}
+		return nil
+	})
+	if a.cancelJob != nil {
+		a.cancelJob()
+		a.cancelJob = nil
+	}
+}
+
+// emitQueueChanged tells the frontend to redraw the queue. CLI mode and
+// tests have no frontend.
+func (a *App) emitQueueChanged() {
+	if a.gui {
+		wailsRuntime.EventsEmit(a.ctx, "queue:changed", a.GetQueue())
+	}
+}
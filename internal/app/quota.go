@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quotaBudgetFor returns the budget config.json sets for key. Budgets are
+// opt-in: 0 and values below zero leave the calls unlimited, so that a
+// paying user is never stopped by a limit they did not set.
+func (a *App) quotaBudgetFor(key string) QuotaBudget {
+	b := a.config.QuotaBudgets[key]
+	b.Daily = max(b.Daily, 0)
+	b.Monthly = max(b.Monthly, 0)
+	return b
+}
+
+// usageKey maps a stats.json record to the limit key of its provider
+func usageKey(r UsageRecord) string {
+	return limitKey(OCRSettings{Provider: r.Provider, OcrSpacePlan: r.Plan})
+}
+
+// quotaUsed counts the calls recorded for key today and this month; the
+// caller holds statsMu
+func (a *App) quotaUsed(key string, now time.Time) (day, month int) {
+	today := now.Format("2006-01-02")
+	thisMonth := now.Format("2006-01")
+	for _, r := range a.stats.Records {
+		if usageKey(r) != key || !strings.HasPrefix(r.Date, thisMonth) {
+			continue
+		}
+		month += r.Count
+		if r.Date == today {
+			day += r.Count
+		}
+	}
+	return day, month
+}
+
+// budgetError is returned instead of calling a provider whose budget is used up
+type budgetError struct {
+	Provider string // limit key
+	Period   string // "day" or "month"
+	Used     int
+	Limit    int
+}
+
+func (e *budgetError) Error() string {
+	period := "monthly"
+	if e.Period == "day" {
+		period = "daily"
+	}
+	return fmt.Sprintf("%s %s quota reached (%d of %d calls)", e.Provider, period, e.Used, e.Limit)
+}
+
+// quotaExceeded returns the budget of key that is used up, counting the
+// calls still in flight, or nil; the caller holds statsMu
+func (a *App) quotaExceeded(key string) *budgetError {
+	b := a.quotaBudgetFor(key)
+	if b.Daily == 0 && b.Monthly == 0 {
+		return nil
+	}
+	day, month := a.quotaUsed(key, time.Now())
+	pending := a.quotaPending[key]
+	if b.Daily > 0 && day+pending >= b.Daily {
+		return &budgetError{Provider: key, Period: "day", Used: day, Limit: b.Daily}
+	}
+	if b.Monthly > 0 && month+pending >= b.Monthly {
+		return &budgetError{Provider: key, Period: "month", Used: month, Limit: b.Monthly}
+	}
+	return nil
+}
+
+// reserveQuota counts a call against the budget of key while it is in
+// flight, so that concurrent workers cannot overshoot it. release is called
+// once the call has been recorded or has failed.
+func (a *App) reserveQuota(key string) (release func(), err error) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	if e := a.quotaExceeded(key); e != nil {
+		return nil, e
+	}
+	if a.quotaPending == nil {
+		a.quotaPending = make(map[string]int)
+	}
+	a.quotaPending[key]++
+	return func() {
+		a.statsMu.Lock()
+		a.quotaPending[key]--
+		a.statsMu.Unlock()
+	}, nil
+}
+
+// quotaWatch reports each budget that runs out during one run once
+type quotaWatch struct {
+	mu       sync.Mutex
+	reported map[string]bool
+}
+
+func (w *quotaWatch) report(a *App, e *budgetError, switchTo string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.reported == nil {
+		w.reported = make(map[string]bool)
+	}
+	if w.reported[e.Provider+"/"+switchTo] {
+		return
+	}
+	w.reported[e.Provider+"/"+switchTo] = true
+
+	q := QuotaStatus{
+		Provider: e.Provider,
+		Period:   e.Period,
+		Used:     e.Used,
+		Limit:    e.Limit,
+		Action:   "stop",
+		SwitchTo: switchTo,
+	}
+	msg := e.Error() + ": stopping"
+	if switchTo != "" {
+		q.Action = "switch"
+		msg = fmt.Sprintf("%s: switching to %s", e.Error(), providerName(switchTo))
+	}
+	a.emitOCRLog("", msg, 0, 0, false)
+	if a.onQuota != nil {
+		a.onQuota(q)
+	}
+}
+
+// withinBudget returns the settings to OCR a page with: unchanged while the
+// provider has budget left, switched to the quota fallback once it is used
+// up, or a *budgetError when neither can take the page; the caller reports
+// that one
+func (a *App) withinBudget(settings OCRSettings, watch *quotaWatch) (OCRSettings, error) {
+	a.statsMu.Lock()
+	e := a.quotaExceeded(limitKey(settings))
+	fallback := settings
+	fallback.Provider = settings.QuotaFallback
+	switchOK := e != nil && fallback.Provider != "" && fallback.Provider != settings.Provider &&
+		a.quotaExceeded(limitKey(fallback)) == nil
+	a.statsMu.Unlock()
+
+	switch {
+	case e == nil:
+		return settings, nil
+	case switchOK:
+		watch.report(a, e, fallback.Provider)
+		return fallback, nil
+	}
+	return settings, e
+}
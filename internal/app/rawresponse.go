@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// rawResponseDir is the folder inside the OCR output folder that holds the
+// provider response for every page, so outputs can be rendered again
+// without calling the provider.
+const rawResponseDir = "raw"
+
+// rawResponsePath returns where the response for page r is stored:
+// raw/<file>[.p<page>].<provider>.json (Vision, OCR.space) or .tsv
+// (Tesseract).
+func rawResponsePath(outputDir string, r PageResult) string {
+	provider := providerName(r.Provider)
+	ext := ".json"
+	if provider == "tesseract" {
+		ext = ".tsv"
+	}
+	name := r.File
+	if r.Page > 0 {
+		name += fmt.Sprintf(".p%d", r.Page)
+	}
+	return filepath.Join(outputDir, rawResponseDir, name+"."+provider+ext)
+}
+
+func saveRawResponse(outputDir string, r PageResult, data []byte) error {
+	path := rawResponsePath(outputDir, r)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("save response: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("save response: %w", err)
+	}
+	return nil
+}
+
+func loadRawResponse(outputDir string, r PageResult) ([]byte, error) {
+	data, err := os.ReadFile(rawResponsePath(outputDir, r))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no stored response for %s", pageResultLabel(r))
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// parseRawResponse turns a stored provider response into the text of one
+// scan. OCR.space and Tesseract give no usable coordinates, so all their
+// text goes on the left page; OCR.space reports no confidence.
+func parseRawResponse(provider string, data []byte, scanMode string) (scanText, error) {
+	switch provider {
+	case "ocrspace":
+		text, err := ocrSpaceText(data)
+		return scanText{Left: text, Confidence: -1, Words: textWords(text)}, err
+	case "tesseract":
+		return scanText{
+			Left:       tesseractTSVText(data),
+			Confidence: tesseractTSVConfidence(data),
+			Words:      tesseractTSVWords(data),
+		}, nil
+	}
+	return visionScanText(data, scanMode)
+}
+
+func pageResultLabel(r PageResult) string {
+	if r.Page > 0 {
+		return fmt.Sprintf("%s page %d", r.File, r.Page)
+	}
+	return r.File
+}
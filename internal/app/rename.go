@@ -10,6 +10,16 @@ import (
 
 // LoadImagesFromFolder scans a directory and returns sorted ImageInfo list
 func (a *App) LoadImagesFromFolder(dir string) ([]ImageInfo, error) {
+	return loadFolder(dir, func(ext string) bool { return imageExts[ext] })
+}
+
+// LoadOCRInputsFromFolder is LoadImagesFromFolder for the OCR tab: it also
+// lists PDF files, which are OCR'd page by page into a searchable copy.
+func (a *App) LoadOCRInputsFromFolder(dir string) ([]ImageInfo, error) {
+	return loadFolder(dir, func(ext string) bool { return imageExts[ext] || ext == ".pdf" })
+}
+
+func loadFolder(dir string, accept func(ext string) bool) ([]ImageInfo, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("read dir: %w", err)
@@ -22,7 +32,7 @@ func (a *App) LoadImagesFromFolder(dir string) ([]ImageInfo, error) {
 			continue
 		}
 		ext := strings.ToLower(filepath.Ext(e.Name()))
-		if !imageExts[ext] {
+		if !accept(ext) {
 			continue
 		}
 		images = append(images, ImageInfo{
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// StartRender regenerates the output PDFs of the selected files from the
+// provider responses stored by earlier OCR runs. No provider is called.
+func (a *App) StartRender(settings OCRSettings) string {
+	return a.startOCRRun(func(ctx context.Context) { a.runRenderPipeline(ctx, settings) })
+}
+
+func (a *App) runRenderPipeline(ctx context.Context, settings OCRSettings) {
+	emitLog := a.emitOCRLog
+
+	files := make([]string, len(settings.SelectedFiles))
+	copy(files, settings.SelectedFiles)
+	sort.Strings(files)
+
+	// Only files OCR'd before have anything to render
+	results := loadPageResults(settings.OutputDir)
+	var todo []string
+	for _, f := range files {
+		if len(results.forFile(filepath.Base(f))) > 0 {
+			todo = append(todo, f)
+		}
+	}
+	if len(todo) == 0 {
+		emitLog("", fmt.Sprintf("No stored OCR results in %s", settings.OutputDir), 0, 0, true)
+		return
+	}
+	emitLog("", fmt.Sprintf("Rendering %d files from stored OCR responses, %d without results skipped",
+		len(todo), len(files)-len(todo)), 0, len(todo), false)
+
+	fontPath := a.cjkFontPath()
+	if fontPath != "" {
+		emitLog("", fmt.Sprintf("Using CJK font: %s", fontPath), 0, 0, false)
+	}
+
+	rendered := 0
+	for i, fp := range todo {
+		if ctx.Err() != nil {
+			emitLog("", "Render stopped by user", 0, len(todo), false)
+			return
+		}
+		baseName := filepath.Base(fp)
+		if err := renderOutput(fp, results.forFile(baseName), settings, fontPath); err != nil {
+			emitLog(baseName, fmt.Sprintf("Error: %v", err), i+1, len(todo), true)
+		} else {
+			emitLog(baseName, "OK", i+1, len(todo), false)
+			rendered++
+		}
+		a.emitOCRProgress(i+1, len(todo))
+	}
+
+	emitLog("", fmt.Sprintf("Render complete! Rendered %d files", rendered), len(todo), len(todo), false)
+
+	if settings.MergePDF {
+		a.mergePDFs(settings.OutputDir, settings.MergeFilename)
+	}
+}
+
+// renderOutput writes the output PDF of filePath again from the stored
+// responses of its pages, the same way the OCR run that produced them did.
+func renderOutput(filePath string, pages []PageResult, settings OCRSettings, fontPath string) error {
+	baseName := filepath.Base(filePath)
+	outputPath := filepath.Join(settings.OutputDir, ocrOutputName(baseName))
+
+	scans := make([]scanText, len(pages))
+	imageOnly, textLayer := true, false
+	for i, r := range pages {
+		textLayer = textLayer || r.TextLayer
+		if r.Provider == imageOnlyProvider {
+			continue
+		}
+		imageOnly = false
+		scan, err := loadPageScan(settings.OutputDir, r, settings.ScanMode)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pageResultLabel(r), err)
+		}
+		scans[i] = scan
+	}
+
+	switch {
+	case isPDFInput(filePath):
+		// PDF input: the text layer goes onto the source pages again
+		dims, err := pdfcpuapi.PageDimsFile(filePath)
+		if err != nil {
+			return fmt.Errorf("read pdf: %w", err)
+		}
+		pageScans := make([]scanText, len(dims))
+		for i, r := range pages {
+			if r.Page >= 1 && r.Page <= len(dims) {
+				pageScans[r.Page-1] = scans[i]
+			}
+		}
+		return stampTextLayer(filePath, outputPath, dims, pageScans, fontPath)
+
+	case imageOnly:
+		_, err := generateImagePDF(outputPath, filePath, settings.ScanMode)
+		return err
+
+	case textLayer:
+		// Image PDF with a text layer added by a later OCR run
+		if _, err := generateImagePDF(outputPath, filePath, settings.ScanMode); err != nil {
+			return err
+		}
+		var layer []scanText
+		for _, scan := range scans {
+			layer = append(layer, splitScanPages(scan, settings.ScanMode)...)
+		}
+		dims, err := pdfcpuapi.PageDimsFile(outputPath)
+		if err != nil {
+			return fmt.Errorf("read pdf: %w", err)
+		}
+		return stampTextLayer(outputPath, "", dims, layer, fontPath)
+	}
+
+	return generateOCRPDF(outputPath, baseName, settings.ScanMode, scans, fontPath)
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// pageResultsFile is written to the OCR output folder and records per-page
+// processing decisions.
+const pageResultsFile = "ocr-results.json"
+
+// pageResults is the in-memory copy of pageResultsFile. Thread-safe; workers
+// update it concurrently.
+type pageResults struct {
+	mu    sync.Mutex
+	path  string
+	pages map[string]PageResult // keyed by pageResultKey
+}
+
+func pageResultKey(p PageResult) string {
+	return fmt.Sprintf("%s#%d", p.File, p.Page)
+}
+
+func loadPageResults(outputDir string) *pageResults {
+	r := &pageResults{
+		path:  filepath.Join(outputDir, pageResultsFile),
+		pages: make(map[string]PageResult),
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return r
+	}
+	var list []PageResult
+	if err := json.Unmarshal(data, &list); err != nil {
+		return r
+	}
+	for _, p := range list {
+		r.pages[pageResultKey(p)] = p
+	}
+	return r
+}
+
+func (r *pageResults) update(p PageResult) {
+	r.mu.Lock()
+	r.pages[pageResultKey(p)] = p
+	r.mu.Unlock()
+}
+
+func (r *pageResults) save() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]PageResult, 0, len(r.pages))
+	for _, p := range r.pages {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].File != list[j].File {
+			return list[i].File < list[j].File
+		}
+		return list[i].Page < list[j].Page
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(r.path, data, 0644)
+}
+
+// imageOnly reports whether the PDF of file was written by images-to-PDF
+// mode and still has no text layer.
+func (r *pageResults) imageOnly(file string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := false
+	for _, p := range r.pages {
+		if p.File != file {
+			continue
+		}
+		if p.Provider != imageOnlyProvider {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// forFile returns the results of every page of file, in page order.
+func (r *pageResults) forFile(file string) []PageResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []PageResult
+	for _, p := range r.pages {
+		if p.File == file {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Page < list[j].Page })
+	return list
+}
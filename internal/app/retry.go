@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Retry defaults, used when neither the job nor config.json sets them
+const (
+	defaultRetries     = 3
+	defaultRetryBaseMs = 1000
+	defaultRetryMaxMs  = 30000
+)
+
+// retryPolicy decides how often and how long to wait before a provider call
+// that failed with a transient error is made again
+type retryPolicy struct {
+	retries   int
+	base, max time.Duration
+}
+
+// retryPolicyFor takes each value from the job settings, then config.json,
+// then the defaults. Retries below zero turn retrying off.
+func (a *App) retryPolicyFor(settings OCRSettings) retryPolicy {
+	pick := func(job, cfg, def int) int {
+		if job != 0 {
+			return job
+		}
+		if cfg != 0 {
+			return cfg
+		}
+		return def
+	}
+	p := retryPolicy{
+		retries: pick(settings.Retries, a.config.Retries, defaultRetries),
+		base:    time.Duration(pick(settings.RetryBaseMs, a.config.RetryBaseMs, defaultRetryBaseMs)) * time.Millisecond,
+		max:     time.Duration(pick(settings.RetryMaxMs, a.config.RetryMaxMs, defaultRetryMaxMs)) * time.Millisecond,
+	}
+	if p.retries < 0 {
+		p.retries = 0
+	}
+	if p.max < p.base {
+		p.max = p.base
+	}
+	return p
+}
+
+// isTransient reports whether an error class is worth retrying: the same
+// request may well succeed a little later
+func isTransient(class string) bool {
+	switch class {
+	case errClassQuota, errClassTimeout, errClassNetwork, errClassServer:
+		return true
+	}
+	return false
+}
+
+// delay returns the wait before retry number n (0-based): base doubled per
+// retry up to max, with jitter over its upper half so that concurrent
+// workers do not retry in lockstep
+func (p retryPolicy) delay(n int) time.Duration {
+	d := p.max
+	if n < 30 && p.base<<n < p.max {
+		d = p.base << n
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// withRetry runs call, and runs it again after a backoff while it fails with
+// a transient error and retries are left. onRetry is told about every retry
+// before the wait.
+func withRetry(ctx context.Context, p retryPolicy, call func() ([]byte, error), onRetry func(n int, wait time.Duration, class string, err error)) ([]byte, error) {
+	for n := 0; ; n++ {
+		data, err := call()
+		if err == nil || ctx.Err() != nil {
+			return data, err
+		}
+		class := classifyOCRError(err)
+		if n >= p.retries || !isTransient(class) {
+			return nil, err
+		}
+		wait := p.delay(n)
+		onRetry(n+1, wait, class, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
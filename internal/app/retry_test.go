@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	p := retryPolicy{retries: 3, base: time.Second, max: 30 * time.Second}
+	for _, n := range []int{0, 1, 2, 4, 5, 6, 29, 30, 31, 62, 63, 64, 1000} {
+		d := p.max
+		if n < 5 {
+			d = p.base << n // 16s at n = 4, 32s at n = 5 is over max
+		}
+		for range 50 {
+			got := p.delay(n)
+			if got < d/2 || got > d {
+				t.Fatalf("delay(%d) = %v, want between %v and %v", n, got, d/2, d)
+			}
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	p := retryPolicy{retries: 3, base: time.Millisecond, max: 2 * time.Millisecond}
+	tests := []struct {
+		name     string
+		policy   retryPolicy
+		errs     []error // returned by successive calls; nil = success
+		calls    int
+		wantErr  bool
+		retryFor string // class reported to onRetry
+	}{
+		{"success", p, []error{nil}, 1, false, ""},
+		{"server error retried until it succeeds", p,
+			[]error{&httpStatusError{Code: 503}, &httpStatusError{Code: 502}, nil}, 3, false, errClassServer},
+		{"quota retried", p, []error{&httpStatusError{Code: 429}, nil}, 2, false, errClassQuota},
+		{"timeout retried", p, []error{fmt.Errorf("call: %w", context.DeadlineExceeded), nil}, 2, false, errClassTimeout},
+		{"retries run out", p, []error{
+			&httpStatusError{Code: 500}, &httpStatusError{Code: 500}, &httpStatusError{Code: 500},
+			&httpStatusError{Code: 500}, nil,
+		}, 4, true, errClassServer},
+		{"auth not retried", p, []error{&httpStatusError{Code: 403}, nil}, 1, true, ""},
+		{"bad input not retried", p, []error{&httpStatusError{Code: 400}, nil}, 1, true, ""},
+		{"local error not retried", p, []error{errors.New("decode: bad JPEG"), nil}, 1, true, ""},
+		{"retries off", retryPolicy{base: time.Millisecond, max: time.Millisecond},
+			[]error{&httpStatusError{Code: 503}, nil}, 1, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			call := func() ([]byte, error) {
+				err := tt.errs[calls]
+				calls++
+				if err != nil {
+					return nil, err
+				}
+				return []byte("ok"), nil
+			}
+			var retries []int
+			onRetry := func(n int, wait time.Duration, class string, err error) {
+				retries = append(retries, n)
+				if class != tt.retryFor {
+					t.Errorf("retry %d: class %q, want %q", n, class, tt.retryFor)
+				}
+				if wait < tt.policy.base/2 || wait > tt.policy.max {
+					t.Errorf("retry %d: wait %v outside %v-%v", n, wait, tt.policy.base/2, tt.policy.max)
+				}
+			}
+
+			data, err := withRetry(context.Background(), tt.policy, call, onRetry)
+			if calls != tt.calls {
+				t.Errorf("%d calls, want %d", calls, tt.calls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && string(data) != "ok" {
+				t.Errorf("data = %q", data)
+			}
+			for i, n := range retries {
+				if n != i+1 {
+					t.Errorf("retries numbered %v", retries)
+					break
+				}
+			}
+			if len(retries) != tt.calls-1 {
+				t.Errorf("%d retries reported, want %d", len(retries), tt.calls-1)
+			}
+		})
+	}
+}
+
+func TestWithRetryCancel(t *testing.T) {
+	p := retryPolicy{retries: 3, base: 10 * time.Second, max: 10 * time.Second}
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	call := func() ([]byte, error) {
+		calls++
+		return nil, &httpStatusError{Code: 503}
+	}
+	start := time.Now()
+	// stopping the run during the backoff ends the wait
+	_, err := withRetry(ctx, p, call, func(int, time.Duration, string, error) {
+		time.AfterFunc(10*time.Millisecond, cancel)
+	})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %v", elapsed)
+	}
+	var httpErr *httpStatusError
+	if !errors.As(err, &httpErr) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the call's error after 1", err, calls)
+	}
+
+	// a call that fails because the run was stopped is not retried
+	calls = 0
+	_, err = withRetry(ctx, p, call, func(int, time.Duration, string, error) {
+		t.Error("retried after the run was stopped")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("err = %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"image"
+	"image/draw"
+)
+
+// orientOp is a lossless 90°-multiple rotation or mirror of an image.
+type orientOp int
+
+const (
+	opRotate90CW orientOp = iota
+	opRotate90CCW
+	opRotate180
+	opFlipH
+	opFlipV
+	opTranspose  // mirror along the top-left/bottom-right diagonal (EXIF 5)
+	opTransverse // mirror along the top-right/bottom-left diagonal (EXIF 7)
+)
+
+func (op orientOp) swapsAxes() bool {
+	switch op {
+	case opRotate90CW, opRotate90CCW, opTranspose, opTransverse:
+		return true
+	}
+	return false
+}
+
+// transformImage applies op to img. Packed-pixel images (Gray, RGBA, NRGBA,
+// their 16-bit variants, CMYK and Paletted) and JPEG's YCbCr are transformed
+// by moving raw bytes without per-pixel color conversion; the result keeps the
+// source type. Anything else is converted to RGBA once and then transformed.
+func transformImage(img image.Image, op orientOp) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	r := image.Rect(0, 0, w, h)
+	if op.swapsAxes() {
+		r = image.Rect(0, 0, h, w)
+	}
+
+	switch src := img.(type) {
+	case *image.YCbCr:
+		if dst := transformYCbCr(src, op); dst != nil {
+			return dst
+		}
+	case *image.Gray:
+		dst := image.NewGray(r)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 1, op)
+		return dst
+	case *image.Gray16:
+		dst := image.NewGray16(r)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 2, op)
+		return dst
+	case *image.RGBA:
+		dst := image.NewRGBA(r)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 4, op)
+		return dst
+	case *image.NRGBA:
+		dst := image.NewNRGBA(r)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 4, op)
+		return dst
+	case *image.CMYK:
+		dst := image.NewCMYK(r)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 4, op)
+		return dst
+	case *image.RGBA64:
+		dst := image.NewRGBA64(r)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 8, op)
+		return dst
+	case *image.NRGBA64:
+		dst := image.NewNRGBA64(r)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 8, op)
+		return dst
+	case *image.Paletted:
+		dst := image.NewPaletted(r, src.Palette)
+		transformPlane(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, 1, op)
+		return dst
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return transformImage(rgba, op)
+}
+
+// transformYCbCr rotates the luma and chroma planes independently, keeping the
+// image in YCbCr. 90° turns swap 4:2:2 and 4:4:0 subsampling. Returns nil when
+// the subsampling or odd dimensions would misalign chroma samples; the caller
+// then falls back to RGBA.
+func transformYCbCr(src *image.YCbCr, op orientOp) *image.YCbCr {
+	var cx, cy int
+	dstRatio := src.SubsampleRatio
+	switch src.SubsampleRatio {
+	case image.YCbCrSubsampleRatio444:
+		cx, cy = 1, 1
+	case image.YCbCrSubsampleRatio420:
+		cx, cy = 2, 2
+	case image.YCbCrSubsampleRatio422:
+		cx, cy = 2, 1
+		if op.swapsAxes() {
+			dstRatio = image.YCbCrSubsampleRatio440
+		}
+	case image.YCbCrSubsampleRatio440:
+		cx, cy = 1, 2
+		if op.swapsAxes() {
+			dstRatio = image.YCbCrSubsampleRatio422
+		}
+	default:
+		return nil
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w%cx != 0 || h%cy != 0 || b.Min.X%cx != 0 || b.Min.Y%cy != 0 {
+		return nil
+	}
+
+	r := image.Rect(0, 0, w, h)
+	if op.swapsAxes() {
+		r = image.Rect(0, 0, h, w)
+	}
+	dst := image.NewYCbCr(r, dstRatio)
+
+	transformPlane(dst.Y, dst.YStride, src.Y[src.YOffset(b.Min.X, b.Min.Y):], src.YStride, w, h, 1, op)
+	ci := src.COffset(b.Min.X, b.Min.Y)
+	transformPlane(dst.Cb, dst.CStride, src.Cb[ci:], src.CStride, w/cx, h/cy, 1, op)
+	transformPlane(dst.Cr, dst.CStride, src.Cr[ci:], src.CStride, w/cx, h/cy, 1, op)
+	return dst
+}
+
+// transformPlaneTile is the edge length, in pixels, of the square blocks used
+// for axis-swapping transforms. Walking the source in tiles keeps the
+// column-wise destination writes within a few cache lines.
+const transformPlaneTile = 64
+
+// transformPlane copies a w×h plane of bpp-byte pixels from src to dst,
+// applying op. For each source row it computes where pixel 0 lands in dst and
+// the byte step between consecutive pixels, so the inner loop is a plain
+// strided copy.
+func transformPlane(dst []byte, dstStride int, src []byte, srcStride, w, h, bpp int, op orientOp) {
+	rowStart := func(y int) (off, step int) {
+		switch op {
+		case opRotate90CW:
+			return (h - 1 - y) * bpp, dstStride
+		case opRotate90CCW:
+			return (w-1)*dstStride + y*bpp, -dstStride
+		case opRotate180:
+			return (h-1-y)*dstStride + (w-1)*bpp, -bpp
+		case opFlipH:
+			return y*dstStride + (w-1)*bpp, -bpp
+		case opTranspose:
+			return y * bpp, dstStride
+		case opTransverse:
+			return (w-1)*dstStride + (h-1-y)*bpp, -dstStride
+		}
+		return (h - 1 - y) * dstStride, bpp // opFlipV
+	}
+
+	if w == 0 || h == 0 {
+		return
+	}
+	tileW, tileH := w, h
+	if op.swapsAxes() {
+		tileW, tileH = transformPlaneTile, transformPlaneTile
+	}
+
+	for y0 := 0; y0 < h; y0 += tileH {
+		y1 := min(y0+tileH, h)
+		for x0 := 0; x0 < w; x0 += tileW {
+			x1 := min(x0+tileW, w)
+			for y := y0; y < y1; y++ {
+				row := src[y*srcStride+x0*bpp : y*srcStride+x1*bpp]
+				off, step := rowStart(y)
+				off += x0 * step
+
+				if step == bpp {
+					copy(dst[off:], row)
+					continue
+				}
+				switch bpp {
+				case 1:
+					for _, v := range row {
+						dst[off] = v
+						off += step
+					}
+				case 4:
+					for x := 0; x < len(row); x += 4 {
+						d := dst[off : off+4 : off+4]
+						d[0], d[1], d[2], d[3] = row[x], row[x+1], row[x+2], row[x+3]
+						off += step
+					}
+				default:
+					for x := 0; x < len(row); x += bpp {
+						copy(dst[off:off+bpp], row[x:x+bpp])
+						off += step
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+var allOrientOps = []orientOp{
+	opRotate90CW, opRotate90CCW, opRotate180, opFlipH, opFlipV, opTranspose, opTransverse,
+}
+
+// orientPoint maps a source pixel to its place in a w×h image after op
+func orientPoint(x, y, w, h int, op orientOp) (int, int) {
+	switch op {
+	case opRotate90CW:
+		return h - 1 - y, x
+	case opRotate90CCW:
+		return y, w - 1 - x
+	case opRotate180:
+		return w - 1 - x, h - 1 - y
+	case opFlipH:
+		return w - 1 - x, y
+	case opFlipV:
+		return x, h - 1 - y
+	case opTranspose:
+		return y, x
+	default: // opTransverse
+		return h - 1 - y, w - 1 - x
+	}
+}
+
+// genericTransform is the per-pixel At/Set path the fast paths replace
+func genericTransform(img image.Image, op orientOp) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if op.swapsAxes() {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := orientPoint(x, y, w, h, op)
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func randomYCbCr(w, h int, ratio image.YCbCrSubsampleRatio) *image.YCbCr {
+	img := image.NewYCbCr(image.Rect(0, 0, w, h), ratio)
+	r := rand.New(rand.NewSource(1))
+	r.Read(img.Y)
+	r.Read(img.Cb)
+	r.Read(img.Cr)
+	return img
+}
+
+func randomRGBA(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rand.New(rand.NewSource(2)).Read(img.Pix)
+	return img
+}
+
+func randomGray(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	rand.New(rand.NewSource(3)).Read(img.Pix)
+	return img
+}
+
+// checkTransform compares transformImage with the generic path pixel by pixel
+func checkTransform(t *testing.T, img image.Image, op orientOp) image.Image {
+	t.Helper()
+	got := transformImage(img, op)
+	want := genericTransform(img, op)
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Dx() != wb.Dx() || gb.Dy() != wb.Dy() {
+		t.Fatalf("op %d: got %v, want %v", op, gb, wb)
+	}
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			g := color.RGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y))
+			if w := want.At(x, y); g != w {
+				t.Fatalf("op %d: pixel (%d,%d) = %v, want %v", op, x, y, g, w)
+			}
+		}
+	}
+	return got
+}
+
+func TestTransformYCbCr(t *testing.T) {
+	ratios := []struct {
+		name  string
+		ratio image.YCbCrSubsampleRatio
+		fast  bool // kept as YCbCr rather than falling back to RGBA
+	}{
+		{"444", image.YCbCrSubsampleRatio444, true},
+		{"422", image.YCbCrSubsampleRatio422, true},
+		{"420", image.YCbCrSubsampleRatio420, true},
+		{"440", image.YCbCrSubsampleRatio440, true},
+		{"411", image.YCbCrSubsampleRatio411, false},
+		{"410", image.YCbCrSubsampleRatio410, false},
+	}
+	for _, r := range ratios {
+		t.Run(r.name, func(t *testing.T) {
+			// 132×72 spans more than one tile; 37×23 has odd sides
+			for _, size := range [][2]int{{132, 72}, {37, 23}} {
+				img := randomYCbCr(size[0], size[1], r.ratio)
+				for _, op := range allOrientOps {
+					got := checkTransform(t, img, op)
+					_, isYCbCr := got.(*image.YCbCr)
+					even := size[0]%2 == 0 && size[1]%2 == 0
+					if want := r.fast && (even || r.ratio == image.YCbCrSubsampleRatio444); isYCbCr != want {
+						t.Errorf("%v op %d: result is %T", size, op, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTransformYCbCrSubImage(t *testing.T) {
+	img := randomYCbCr(96, 64, image.YCbCrSubsampleRatio420)
+	sub := img.SubImage(image.Rect(10, 6, 80, 50))
+	for _, op := range allOrientOps {
+		checkTransform(t, sub, op)
+	}
+}
+
+func TestTransformRGBA(t *testing.T) {
+	for _, size := range [][2]int{{132, 72}, {37, 23}} {
+		img := randomRGBA(size[0], size[1])
+		for _, op := range allOrientOps {
+			if got := checkTransform(t, img, op); !isType[*image.RGBA](got) {
+				t.Errorf("op %d: result is %T", op, got)
+			}
+		}
+	}
+	sub := randomRGBA(96, 64).SubImage(image.Rect(5, 3, 70, 41))
+	for _, op := range allOrientOps {
+		checkTransform(t, sub, op)
+	}
+}
+
+func TestTransformGray(t *testing.T) {
+	for _, size := range [][2]int{{132, 72}, {37, 23}} {
+		img := randomGray(size[0], size[1])
+		for _, op := range allOrientOps {
+			if got := checkTransform(t, img, op); !isType[*image.Gray](got) {
+				t.Errorf("op %d: result is %T", op, got)
+			}
+		}
+	}
+	sub := randomGray(96, 64).SubImage(image.Rect(5, 3, 70, 41))
+	for _, op := range allOrientOps {
+		checkTransform(t, sub, op)
+	}
+}
+
+func isType[T image.Image](img image.Image) bool {
+	_, ok := img.(T)
+	return ok
+}
+
+// The benchmarks run each fast path and the generic path on a 6000×4000
+// photo; the op is the one EXIF orientation 6 needs.
+const benchW, benchH = 6000, 4000
+
+func benchTransform(b *testing.B, img image.Image, transform func(image.Image, orientOp) image.Image) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		transform(img, opRotate90CW)
+	}
+}
+
+func genericImage(img image.Image, op orientOp) image.Image { return genericTransform(img, op) }
+
+func BenchmarkTransformYCbCr420(b *testing.B) {
+	benchTransform(b, randomYCbCr(benchW, benchH, image.YCbCrSubsampleRatio420), transformImage)
+}
+
+func BenchmarkTransformYCbCr420Generic(b *testing.B) {
+	benchTransform(b, randomYCbCr(benchW, benchH, image.YCbCrSubsampleRatio420), genericImage)
+}
+
+func BenchmarkTransformYCbCr444(b *testing.B) {
+	benchTransform(b, randomYCbCr(benchW, benchH, image.YCbCrSubsampleRatio444), transformImage)
+}
+
+func BenchmarkTransformYCbCr444Generic(b *testing.B) {
+	benchTransform(b, randomYCbCr(benchW, benchH, image.YCbCrSubsampleRatio444), genericImage)
+}
+
+func BenchmarkTransformRGBA(b *testing.B) {
+	benchTransform(b, randomRGBA(benchW, benchH), transformImage)
+}
+
+func BenchmarkTransformRGBAGeneric(b *testing.B) {
+	benchTransform(b, randomRGBA(benchW, benchH), genericImage)
+}
+
+func BenchmarkTransformGray(b *testing.B) {
+	benchTransform(b, randomGray(benchW, benchH), transformImage)
+}
+
+func BenchmarkTransformGrayGeneric(b *testing.B) {
+	benchTransform(b, randomGray(benchW, benchH), genericImage)
+}
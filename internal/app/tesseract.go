@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -28,42 +29,225 @@ var tesseractLangMap = map[string]string{
 	"pt":    "por",
 }
 
-// callTesseract runs tesseract.exe as a subprocess and returns extracted text
-func (a *App) callTesseract(filePath string, settings OCRSettings) (string, error) {
-	tesseractPath := settings.TesseractPath
-	if tesseractPath == "" {
-		return "", fmt.Errorf("tesseract path not configured")
-	}
-
-	// Build language argument
-	var langParts []string
-	for _, lang := range settings.Languages {
+// tesseractLangs maps internal language codes to Tesseract's, English when
+// none is known
+func tesseractLangs(languages []string) []string {
+	var langs []string
+	for _, lang := range languages {
 		if mapped, ok := tesseractLangMap[lang]; ok {
-			langParts = append(langParts, mapped)
+			langs = append(langs, mapped)
 		}
 	}
-	if len(langParts) == 0 {
-		langParts = []string{"eng"}
+	if len(langs) == 0 {
+		langs = []string{"eng"}
 	}
-	langArg := strings.Join(langParts, "+")
+	return langs
+}
 
-	// Build command: tesseract <image> stdout -l <lang>
-	ctx, cancel := context.WithTimeout(a.ctx, 120*time.Second)
+// callTesseract runs tesseract.exe as a subprocess and returns its TSV
+// output; tesseractTSVText extracts the text from it
+func (a *App) callTesseract(ctx context.Context, filePath string, settings OCRSettings) ([]byte, error) {
+	tesseractPath := settings.TesseractPath
+	if tesseractPath == "" {
+		return nil, fmt.Errorf("tesseract path not configured")
+	}
+
+	langArg := strings.Join(tesseractLangs(settings.Languages), "+")
+
+	// Build command: tesseract <image> stdout -l <lang> tsv
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, tesseractPath, filePath, "stdout", "-l", langArg)
+	// Stopping the pipeline kills tesseract; WaitDelay keeps a child process
+	// holding stdout from blocking the return
+	cmd := exec.CommandContext(ctx, tesseractPath, filePath, "stdout", "-l", langArg, "tsv")
+	cmd.WaitDelay = 5 * time.Second
 	hideCommandWindow(cmd)
 	output, err := cmd.Output()
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("tesseract: %w", ctx.Err())
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("tesseract: %s", string(exitErr.Stderr))
+			return nil, fmt.Errorf("tesseract: %s", string(exitErr.Stderr))
 		}
-		return "", fmt.Errorf("tesseract: %w", err)
+		return nil, fmt.Errorf("tesseract: %w", err)
 	}
 
 	a.RecordApiCall("tesseract", "local")
 
-	return strings.TrimSpace(string(output)), nil
+	return output, nil
+}
+
+// tesseractInstall is what the OCR cache key knows about a Tesseract binary
+type tesseractInstall struct {
+	binary   string // size and modification time, to notice an upgrade in place
+	version  string // tesseract --version
+	tessdata string // traineddata folder, "" when tesseract does not name it
+}
+
+// tesseractInstallFor asks the binary at path for its version and
+// traineddata folder once, and again only when the binary changes
+func (a *App) tesseractInstallFor(path string) tesseractInstall {
+	binary := ""
+	if fi, err := os.Stat(path); err == nil {
+		binary = fmt.Sprintf("%d %d", fi.Size(), fi.ModTime().UnixNano())
+	}
+	a.tesseractsMu.Lock()
+	defer a.tesseractsMu.Unlock()
+	if t, ok := a.tesseracts[path]; ok && t.binary == binary {
+		return t
+	}
+	t := tesseractInstall{
+		binary:   binary,
+		version:  strings.TrimSpace(string(tesseractOutput(path, "--version"))),
+		tessdata: tessdataDir(tesseractOutput(path, "--list-langs")),
+	}
+	if a.tesseracts == nil {
+		a.tesseracts = make(map[string]tesseractInstall)
+	}
+	a.tesseracts[path] = t
+	return t
+}
+
+// tesseractOutput runs tesseract with one option and returns what it
+// printed; older versions print --version to stderr
+func tesseractOutput(path, option string) []byte {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, option)
+	hideCommandWindow(cmd)
+	out, _ := cmd.CombinedOutput()
+	return out
+}
+
+// tessdataDir finds the folder in --list-langs output, which starts
+// `List of available languages in "/usr/share/tessdata/" (3):`
+func tessdataDir(listLangs []byte) string {
+	s := string(listLangs)
+	i := strings.Index(s, `in "`)
+	if i < 0 {
+		return ""
+	}
+	s = s[i+len(`in "`):]
+	j := strings.IndexByte(s, '"')
+	if j < 0 {
+		return ""
+	}
+	return s[:j]
+}
+
+// tesseractCacheID identifies the Tesseract behind a run for its cache key:
+// the binary, its version and the traineddata of the run's languages, so
+// that an upgrade or a swapped language pack is not answered from old
+// entries
+func (a *App) tesseractCacheID(settings OCRSettings) string {
+	path := settings.TesseractPath
+	if path == "" {
+		return ""
+	}
+	t := a.tesseractInstallFor(path)
+	id := path + "\x00" + t.version
+	if t.tessdata != "" {
+		for _, lang := range tesseractLangs(settings.Languages) {
+			if fi, err := os.Stat(filepath.Join(t.tessdata, lang+".traineddata")); err == nil {
+				id += fmt.Sprintf("\x00%s %d %d", lang, fi.Size(), fi.ModTime().UnixNano())
+			}
+		}
+	}
+	return id
+}
+
+// tesseractTSVText rebuilds plain text from Tesseract TSV output: words of a
+// line joined by spaces, lines by newlines, and a blank line between blocks,
+// as in Tesseract's own text output.
+func tesseractTSVText(tsv []byte) string {
+	var sb strings.Builder
+	lastBlock, lastLine := "", ""
+	for i, row := range strings.Split(string(tsv), "\n") {
+		// level page block par line word left top width height conf text
+		cols := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if i == 0 || len(cols) < 12 || cols[0] != "5" {
+			continue
+		}
+		word := strings.TrimSpace(cols[11])
+		if word == "" {
+			continue
+		}
+		block := cols[1] + "." + cols[2]
+		line := block + "." + cols[3] + "." + cols[4]
+		switch {
+		case lastBlock == "":
+		case block != lastBlock:
+			sb.WriteString("\n\n")
+		case line != lastLine:
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(" ")
+		}
+		sb.WriteString(word)
+		lastBlock, lastLine = block, line
+	}
+	return sb.String()
+}
+
+// tesseractTSVConfidence is the mean conf column of the words in Tesseract
+// TSV output, -1 when there are none
+func tesseractTSVConfidence(tsv []byte) float64 {
+	sum, n := 0.0, 0
+	for i, row := range strings.Split(string(tsv), "\n") {
+		cols := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if i == 0 || len(cols) < 12 || cols[0] != "5" || strings.TrimSpace(cols[11]) == "" {
+			continue
+		}
+		// Rows that are not words carry conf -1
+		conf, err := strconv.ParseFloat(cols[10], 64)
+		if err != nil || conf < 0 {
+			continue
+		}
+		sum += conf
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return sum / float64(n)
+}
+
+// tesseractTSVWords returns the words of Tesseract TSV output with their
+// confidences, separated the way tesseractTSVText joins them
+func tesseractTSVWords(tsv []byte) []ocrWord {
+	var words []ocrWord
+	lastBlock, lastLine := "", ""
+	for i, row := range strings.Split(string(tsv), "\n") {
+		cols := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if i == 0 || len(cols) < 12 || cols[0] != "5" {
+			continue
+		}
+		text := strings.TrimSpace(cols[11])
+		if text == "" {
+			continue
+		}
+		block := cols[1] + "." + cols[2]
+		line := block + "." + cols[3] + "." + cols[4]
+		if n := len(words); n > 0 {
+			switch {
+			case block != lastBlock:
+				words[n-1].Sep = "\n\n"
+			case line != lastLine:
+				words[n-1].Sep = "\n"
+			default:
+				words[n-1].Sep = " "
+			}
+		}
+		conf := -1.0
+		if c, err := strconv.ParseFloat(cols[10], 64); err == nil && c >= 0 {
+			conf = c / 100
+		}
+		words = append(words, ocrWord{Text: text, Conf: conf})
+		lastBlock, lastLine = block, line
+	}
+	return words
 }
 
 // DetectTesseract tries to auto-detect the tesseract.exe path.
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	vision "cloud.google.com/go/vision/v2/apiv1"
+	"cloud.google.com/go/vision/v2/apiv1/visionpb"
+)
+
+const (
+	// visionBatchMax is the most images BatchAnnotateImages takes in one call
+	visionBatchMax = 16
+	// visionBatchBytes keeps a batch of image content under the API's
+	// request size limit; a larger image goes alone
+	visionBatchBytes = 10 << 20
+	// visionBatchLinger is how long the first request of a batch waits for
+	// more while some workers are still busy with other steps
+	visionBatchLinger = 100 * time.Millisecond
+)
+
+// visionBatchFor returns the batch size of a run: the job's, then
+// config.json's, then visionBatchMax. 1 sends one image per call.
+func (a *App) visionBatchFor(settings OCRSettings) int {
+	n := settings.VisionBatch
+	if n == 0 {
+		n = a.config.VisionBatch
+	}
+	if n <= 0 || n > visionBatchMax {
+		n = visionBatchMax
+	}
+	return n
+}
+
+// visionBatcher groups the Vision requests of concurrent workers into
+// BatchAnnotateImages calls and hands each worker its own response, so that
+// an error in one image fails only that page. Thread-safe.
+type visionBatcher struct {
+	ctx     context.Context // the run's; a batch is not cut off by one page
+	client  *vision.ImageAnnotatorClient
+	workers *concurrencyController
+	max     int
+
+	mu      sync.Mutex
+	pending []*visionJob
+	bytes   int
+	timer   *time.Timer
+}
+
+type visionJob struct {
+	req  *visionpb.AnnotateImageRequest
+	size int
+	done chan visionReply
+}
+
+type visionReply struct {
+	resp *visionpb.AnnotateImageResponse
+	err  error
+}
+
+func newVisionBatcher(ctx context.Context, client *vision.ImageAnnotatorClient, workers *concurrencyController, size int) *visionBatcher {
+	return &visionBatcher{ctx: ctx, client: client, workers: workers, max: size}
+}
+
+// annotate queues req and waits for its response. A batch is sent once it
+// is full, once every busy worker is waiting in it, or after
+// visionBatchLinger.
+func (b *visionBatcher) annotate(ctx context.Context, req *visionpb.AnnotateImageRequest, size int) (*visionpb.AnnotateImageResponse, error) {
+	job := &visionJob{req: req, size: size, done: make(chan visionReply, 1)}
+
+	b.mu.Lock()
+	if len(b.pending) > 0 && b.bytes+size > visionBatchBytes {
+		go b.send(b.take())
+	}
+	b.pending = append(b.pending, job)
+	b.bytes += size
+	switch {
+	case len(b.pending) >= b.max || len(b.pending) >= b.workers.busy():
+		go b.send(b.take())
+	case len(b.pending) == 1:
+		b.timer = time.AfterFunc(visionBatchLinger, b.flush)
+	}
+	b.mu.Unlock()
+
+	select {
+	case r := <-job.done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// take empties the pending batch; the caller holds mu
+func (b *visionBatcher) take() []*visionJob {
+	batch := b.pending
+	b.pending, b.bytes = nil, 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch
+}
+
+func (b *visionBatcher) flush() {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+	if len(batch) > 0 {
+		b.send(batch)
+	}
+}
+
+// send makes one BatchAnnotateImages call for batch. A failed call fails
+// every image in it; each is retried on its own.
+func (b *visionBatcher) send(batch []*visionJob) {
+	reqs := make([]*visionpb.AnnotateImageRequest, len(batch))
+	for i, job := range batch {
+		reqs[i] = job.req
+	}
+	resp, err := b.client.BatchAnnotateImages(b.ctx, &visionpb.BatchAnnotateImagesRequest{Requests: reqs})
+	if err == nil && len(resp.Responses) != len(batch) {
+		err = fmt.Errorf("%d responses for %d images", len(resp.Responses), len(batch))
+	}
+	for i, job := range batch {
+		if err != nil {
+			job.done <- visionReply{err: err}
+		} else {
+			job.done <- visionReply{resp: resp.Responses[i]}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/vision/v2/apiv1/visionpb"
+	"google.golang.org/grpc/status"
+)
+
+// newStubBatcher connects a batcher of up to size images to a stub Vision
+// server, with busy workers holding a slot of the pool
+func newStubBatcher(t *testing.T, size, busy int) (*visionBatcher, *stubVision) {
+	t.Helper()
+	stub, endpoint := startStubVision(t)
+	ctx := context.Background()
+	client, err := NewApp().newVisionClient(ctx, OCRSettings{
+		VisionEndpoint: endpoint,
+		VisionInsecure: true,
+		VisionAuth:     visionAuthNone,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	workers := newConcurrencyController(busy, busy, nil)
+	for range busy {
+		if err := workers.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return newVisionBatcher(ctx, client, workers, size), stub
+}
+
+type batchReply struct {
+	text string
+	err  error
+}
+
+// annotateAll sends every image at once, as concurrent workers do, and
+// returns what each caller got back. sizes overrides the byte count the
+// batcher is told for each image.
+func annotateAll(b *visionBatcher, images [][]byte, sizes []int) []batchReply {
+	replies := make([]batchReply, len(images))
+	var wg sync.WaitGroup
+	for i, img := range images {
+		size := len(img)
+		if sizes != nil {
+			size = sizes[i]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := &visionpb.AnnotateImageRequest{Image: &visionpb.Image{Content: img}}
+			resp, err := b.annotate(context.Background(), req, size)
+			replies[i].err = err
+			if err == nil {
+				if resp.Error != nil {
+					replies[i].err = status.ErrorProto(resp.Error)
+				} else {
+					replies[i].text = resp.GetFullTextAnnotation().GetText()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return replies
+}
+
+// batchSizes returns the images of each call the stub received, in order
+func (s *stubVision) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.batches)
+}
+
+func TestVisionBatcherFull(t *testing.T) {
+	b, stub := newStubBatcher(t, 4, 8)
+	var images [][]byte
+	for i := range 8 {
+		w := 100 + i
+		if i == 5 {
+			w = badSide
+		}
+		images = append(images, blankJPEG(t, w, 50))
+	}
+
+	replies := annotateAll(b, images, nil)
+	if got := stub.batchSizes(); !slices.Equal(got, []int{4, 4}) {
+		t.Errorf("batches %v, want [4 4]", got)
+	}
+	for i, r := range replies {
+		switch {
+		case i == 5:
+			if r.err == nil || !strings.Contains(r.err.Error(), "bad image") {
+				t.Errorf("image %d: err %v, want bad image", i, r.err)
+			}
+		case r.err != nil || r.text != "stub page":
+			t.Errorf("image %d: %q, %v", i, r.text, r.err)
+		}
+	}
+}
+
+func TestVisionBatcherAllWaiting(t *testing.T) {
+	// every busy worker is waiting: the batch goes without lingering
+	b, stub := newStubBatcher(t, 16, 3)
+	images := [][]byte{blankJPEG(t, 100, 50), blankJPEG(t, 101, 50), blankJPEG(t, 102, 50)}
+	start := time.Now()
+	for i, r := range annotateAll(b, images, nil) {
+		if r.err != nil {
+			t.Errorf("image %d: %v", i, r.err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= visionBatchLinger {
+		t.Errorf("took %v, sent only after the linger", elapsed)
+	}
+	if got := stub.batchSizes(); !slices.Equal(got, []int{3}) {
+		t.Errorf("batches %v, want [3]", got)
+	}
+}
+
+func TestVisionBatcherLinger(t *testing.T) {
+	// two of five busy workers are elsewhere: the batch waits for them
+	// until visionBatchLinger
+	b, stub := newStubBatcher(t, 16, 5)
+	images := [][]byte{blankJPEG(t, 100, 50), blankJPEG(t, 101, 50), blankJPEG(t, 102, 50)}
+	start := time.Now()
+	annotateAll(b, images, nil)
+	if elapsed := time.Since(start); elapsed < visionBatchLinger {
+		t.Errorf("took %v, sent before the linger", elapsed)
+	}
+	if got := stub.batchSizes(); !slices.Equal(got, []int{3}) {
+		t.Errorf("batches %v, want [3]", got)
+	}
+}
+
+func TestVisionBatcherBytes(t *testing.T) {
+	// two images of 2/5 of the limit fit in one call, a third does not
+	b, stub := newStubBatcher(t, 16, 3)
+	images := [][]byte{blankJPEG(t, 100, 50), blankJPEG(t, 101, 50), blankJPEG(t, 102, 50)}
+	size := visionBatchBytes * 2 / 5
+	for i, r := range annotateAll(b, images, []int{size, size, size}) {
+		if r.err != nil {
+			t.Errorf("image %d: %v", i, r.err)
+		}
+	}
+	if got := stub.batchSizes(); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("batches %v, want [2 1]", got)
+	}
+}
+
+func TestVisionBatcherShortResponse(t *testing.T) {
+	// responses cannot be matched to images: every image of the call fails
+	b, stub := newStubBatcher(t, 16, 3)
+	stub.short = true
+	images := [][]byte{blankJPEG(t, 100, 50), blankJPEG(t, 101, 50), blankJPEG(t, 102, 50)}
+	for i, r := range annotateAll(b, images, nil) {
+		if r.err == nil || !strings.Contains(r.err.Error(), "2 responses for 3 images") {
+			t.Errorf("image %d: err %v", i, r.err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	vision "cloud.google.com/go/vision/v2/apiv1"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Google Vision auth modes
+const (
+	visionAuthFile   = "file"   // service account key file (CredFile)
+	visionAuthADC    = "adc"    // Application Default Credentials
+	visionAuthAPIKey = "apikey" // API key
+	visionAuthNone   = "none"   // no credentials, for a local emulator
+)
+
+// visionAuthFor returns the auth mode of a run: the job's, then
+// config.json's, then the key file
+func (a *App) visionAuthFor(settings OCRSettings) string {
+	auth := settings.VisionAuth
+	if auth == "" {
+		auth = a.config.VisionAuth
+	}
+	if auth == "" {
+		auth = visionAuthFile
+	}
+	return auth
+}
+
+// visionEndpointFor returns the Vision endpoint of a run and whether it is
+// dialed without TLS: the job's, then config.json's. "" is the public API.
+func (a *App) visionEndpointFor(settings OCRSettings) (string, bool) {
+	endpoint := settings.VisionEndpoint
+	if endpoint == "" {
+		endpoint = a.config.VisionEndpoint
+	}
+	return endpoint, settings.VisionInsecure || a.config.VisionInsecure
+}
+
+// newVisionClient creates the Vision API client of a run. The endpoint,
+// plaintext mode and auth come from the job, then config.json; plaintext
+// dials the endpoint without TLS, as a local emulator or stub server
+// expects; closing the client closes that connection too.
+func (a *App) newVisionClient(ctx context.Context, settings OCRSettings) (*vision.ImageAnnotatorClient, error) {
+	endpoint, plaintext := a.visionEndpointFor(settings)
+
+	var opts []option.ClientOption
+	switch auth := a.visionAuthFor(settings); auth {
+	case visionAuthFile:
+		opts = append(opts, option.WithCredentialsFile(settings.CredFile))
+	case visionAuthADC:
+	case visionAuthAPIKey:
+		key := settings.VisionApiKey
+		if key == "" {
+			key = a.config.VisionApiKey
+		}
+		if key == "" {
+			return nil, fmt.Errorf("no Google Vision API key")
+		}
+		if plaintext {
+			// gRPC only sends an API key over TLS
+			return nil, fmt.Errorf("an API key cannot be sent to a plaintext endpoint")
+		}
+		opts = append(opts, option.WithAPIKey(key))
+	case visionAuthNone:
+		opts = append(opts, option.WithoutAuthentication())
+	default:
+		return nil, fmt.Errorf("unknown Google Vision auth %q (use file, adc, apikey or none)", auth)
+	}
+
+	if !plaintext {
+		if endpoint != "" {
+			opts = append(opts, option.WithEndpoint(endpoint))
+		}
+		return vision.NewImageAnnotatorClient(ctx, opts...)
+	}
+
+	if endpoint == "" {
+		return nil, fmt.Errorf("plaintext mode needs a Vision endpoint")
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	client, err := vision.NewImageAnnotatorClient(ctx, append(opts, option.WithGRPCConn(conn))...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
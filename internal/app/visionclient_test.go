@@ -0,0 +1,217 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/vision/v2/apiv1/visionpb"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// stubVision answers every image with "stub page" and rejects images with a
+// side of badSide pixels
+type stubVision struct {
+	visionpb.UnimplementedImageAnnotatorServer
+	mu      sync.Mutex
+	images  int
+	batches []int // images per BatchAnnotateImages call
+	short   bool  // answer one response fewer than asked
+}
+
+const badSide = 300
+
+func (s *stubVision) BatchAnnotateImages(ctx context.Context, req *visionpb.BatchAnnotateImagesRequest) (*visionpb.BatchAnnotateImagesResponse, error) {
+	s.mu.Lock()
+	s.images += len(req.Requests)
+	s.batches = append(s.batches, len(req.Requests))
+	short := s.short
+	s.mu.Unlock()
+
+	out := &visionpb.BatchAnnotateImagesResponse{}
+	for _, r := range req.Requests {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(r.GetImage().GetContent()))
+		switch {
+		case err != nil:
+			out.Responses = append(out.Responses, &visionpb.AnnotateImageResponse{
+				Error: &statuspb.Status{Code: int32(codes.InvalidArgument), Message: "cannot decode image"},
+			})
+		case cfg.Width == badSide || cfg.Height == badSide:
+			out.Responses = append(out.Responses, &visionpb.AnnotateImageResponse{
+				Error: &statuspb.Status{Code: int32(codes.InvalidArgument), Message: "bad image"},
+			})
+		default:
+			out.Responses = append(out.Responses, &visionpb.AnnotateImageResponse{
+				FullTextAnnotation: &visionpb.TextAnnotation{Text: "stub page"},
+			})
+		}
+	}
+	if short {
+		out.Responses = out.Responses[1:]
+	}
+	return out, nil
+}
+
+// startStubVision serves stubVision on a local port without TLS
+func startStubVision(t *testing.T) (*stubVision, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	stub := &stubVision{}
+	visionpb.RegisterImageAnnotatorServer(srv, stub)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return stub, lis.Addr().String()
+}
+
+// blankJPEG encodes a white w×h page
+func blankJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeBlankPage writes a white w×h JPEG page
+func writeBlankPage(t *testing.T, path string, w, h int) {
+	t.Helper()
+	if err := os.WriteFile(path, blankJPEG(t, w, h), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestApp returns an App whose events go to the test instead of Wails
+func newTestApp(t *testing.T) (*App, *[]LogEntry) {
+	a := NewApp()
+	a.sessions = map[string]*Session{}
+	var mu sync.Mutex
+	var logs []LogEntry
+	a.onLog = func(e LogEntry) {
+		mu.Lock()
+		logs = append(logs, e)
+		mu.Unlock()
+	}
+	a.onProgress = func(ProgressUpdate) {}
+	a.onFinished = func() {}
+	return a, &logs
+}
+
+func TestVisionStubPipeline(t *testing.T) {
+	stub, endpoint := startStubVision(t)
+
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	if err := os.Mkdir(in, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]int{"p001.jpg": 200, "p002.jpg": 240, "p003.jpg": badSide}
+	var selected []string
+	for name, w := range pages {
+		path := filepath.Join(in, name)
+		writeBlankPage(t, path, w, 100)
+		selected = append(selected, path)
+	}
+
+	a, logs := newTestApp(t)
+	err := a.runOCRPipeline(context.Background(), OCRSettings{
+		SelectedFiles:  selected,
+		ImageDir:       in,
+		OutputDir:      out,
+		Provider:       "google",
+		ScanMode:       "single",
+		Languages:      []string{"en"},
+		NoCache:        true,
+		VisionEndpoint: endpoint,
+		VisionInsecure: true,
+		VisionAuth:     visionAuthNone,
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 files failed") {
+		t.Fatalf("pipeline: %v, want 1 of 3 files failed", err)
+	}
+	if stub.images == 0 {
+		t.Fatal("the stub server was never called")
+	}
+
+	// each page OCR'd becomes a PDF, with the stub's answer kept in raw/
+	for _, name := range []string{"p001", "p002"} {
+		pdf, err := os.ReadFile(filepath.Join(out, name+".pdf"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+			t.Errorf("%s.pdf is not a PDF", name)
+		}
+		raw, err := os.ReadFile(rawResponsePath(out, PageResult{File: name + ".jpg", Provider: "google"}))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !strings.Contains(string(raw), "stub page") {
+			t.Errorf("%s response = %s", name, raw)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "p003.pdf")); err == nil {
+		t.Error("p003.pdf written for a rejected page")
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, failuresFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []FailureRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("failures = %+v, want one", records)
+	}
+	if r := records[0]; r.File != "p003.jpg" || r.Provider != "google" || r.Class != errClassInput || !strings.Contains(r.Error, "bad image") {
+		t.Errorf("failure = %+v", r)
+	}
+
+	var fileErrors int
+	for _, e := range *logs {
+		if e.IsError {
+			fileErrors++
+			if e.Filename != "p003.jpg" {
+				t.Errorf("error logged for %q: %s", e.Filename, e.Message)
+			}
+		}
+	}
+	if fileErrors == 0 {
+		t.Error("no error logged for p003.jpg")
+	}
+}
+
+func TestNewVisionClientPlaintext(t *testing.T) {
+	a := NewApp()
+	ctx := context.Background()
+	if _, err := a.newVisionClient(ctx, OCRSettings{VisionInsecure: true, VisionAuth: visionAuthNone}); err == nil {
+		t.Error("plaintext without an endpoint accepted")
+	}
+	apiKey := OCRSettings{VisionEndpoint: "127.0.0.1:1", VisionInsecure: true, VisionAuth: visionAuthAPIKey, VisionApiKey: "k"}
+	if _, err := a.newVisionClient(ctx, apiKey); err == nil {
+		t.Error("API key sent to a plaintext endpoint")
+	}
+	if _, err := a.newVisionClient(ctx, OCRSettings{VisionAuth: "bogus"}); err == nil {
+		t.Error("unknown auth accepted")
+	}
+}
@@ -16,9 +16,15 @@ import (
 var assets embed.FS
 
 func main() {
-	if len(os.Args) > 1 && os.Args[1] == "ocr" {
+	if len(os.Args) > 1 && (os.Args[1] == "ocr" || os.Args[1] == "render") {
 		os.Exit(app.RunCLI(os.Args))
 	}
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		os.Exit(app.RunCacheCLI(os.Args))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "queue" {
+		os.Exit(app.RunQueueCLI(os.Args))
+	}
 
 	a := app.NewApp()
 